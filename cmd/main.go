@@ -7,9 +7,12 @@ import (
 	"fmt"
 	"net/url"
 	"os"
+	"strconv"
 	"strings"
 	"time"
 
+	keptnmodels "github.com/keptn/go-utils/pkg/api/models"
+	keptnapi "github.com/keptn/go-utils/pkg/api/utils"
 	keptncommon "github.com/keptn/go-utils/pkg/lib"
 	log "github.com/sirupsen/logrus"
 
@@ -32,6 +35,9 @@ import (
 
 const ProblemOpenSLI = "problem_open"
 
+// keptnSpecVersion is set on events sent through the Keptn API - the distributor sets it for us otherwise
+const keptnSpecVersion = "0.2.1"
+
 type envConfig struct {
 	// Port on which to listen for cloudevents
 	Port int    `envconfig:"RCV_PORT" default:"8080"`
@@ -200,7 +206,7 @@ func addSLO(keptnEvent *common.BaseKeptnEvent, newSLO *keptncommon.SLO) error {
 /**
  * Tries to find a dynatrace dashboard that matches our project. If so - returns the SLI, SLO and SLIResults
  */
-func getDataFromDynatraceDashboard(dynatraceHandler *dynatrace.Handler, keptnEvent *common.BaseKeptnEvent, startUnix time.Time, endUnix time.Time, dashboardConfig string) (string, []*keptnv2.SLIResult, error) {
+func getDataFromDynatraceDashboard(dynatraceHandler *dynatrace.Handler, keptnEvent *common.BaseKeptnEvent, startUnix time.Time, endUnix time.Time, dashboardConfig string, writeMode string) (string, []*keptnv2.SLIResult, error) {
 
 	//
 	// Option 1: We query the data from a dashboard instead of the uploaded SLI.yaml
@@ -211,8 +217,8 @@ func getDataFromDynatraceDashboard(dynatraceHandler *dynatrace.Handler, keptnEve
 		return dashboardLinkAsLabel, sliResults, fmt.Errorf("could not query Dynatrace dashboard for SLIs: %v", err)
 	}
 
-	// lets store the dashboard as well
-	if dashboardJSON != nil {
+	// lets store the dashboard as well - unless the dashboard in the repo is our source of truth
+	if dashboardJSON != nil && dashboardConfig != common.DynatraceConfigDashboardREPO {
 		jsonAsByteArray, _ := json.MarshalIndent(dashboardJSON, "", "  ")
 
 		err := common.UploadKeptnResource(jsonAsByteArray, common.DynatraceDashboardFilename, keptnEvent)
@@ -221,23 +227,57 @@ func getDataFromDynatraceDashboard(dynatraceHandler *dynatrace.Handler, keptnEve
 		}
 	}
 
+	err = uploadDashboardSLIAndSLO(keptnEvent, dashboardSLI, dashboardSLO, sliResults, writeMode)
+	if err != nil {
+		return dashboardLinkAsLabel, sliResults, err
+	}
+
+	return dashboardLinkAsLabel, sliResults, nil
+}
+
+/**
+ * Merges the SLIs of all dashboards listed in dynatrace.conf.yaml:dashboards. Returns the links to all dashboards, the SLIResults and an error if merging failed
+ */
+func getDataFromDynatraceDashboards(dynatraceHandler *dynatrace.Handler, keptnEvent *common.BaseKeptnEvent, startUnix time.Time, endUnix time.Time, dashboards []common.DynatraceConfigDashboard, writeMode string) ([]string, []*keptnv2.SLIResult, error) {
+	dashboardLinks, dashboardSLI, dashboardSLO, sliResults, err := dynatraceHandler.QueryDynatraceDashboardsForSLIs(keptnEvent, dashboards, startUnix, endUnix)
+	if err != nil {
+		return dashboardLinks, nil, err
+	}
+
+	err = uploadDashboardSLIAndSLO(keptnEvent, dashboardSLI, dashboardSLO, sliResults, writeMode)
+	if err != nil {
+		return dashboardLinks, sliResults, err
+	}
+
+	return dashboardLinks, sliResults, nil
+}
+
+/**
+ * Writes the SLI & SLO that were generated from dashboards to the config repo
+ * writeMode (dynatrace.conf.yaml:writeMode) decides whether they overwrite the existing files, are merged into them or are not written at all
+ */
+func uploadDashboardSLIAndSLO(keptnEvent *common.BaseKeptnEvent, dashboardSLI *dynatrace.SLI, dashboardSLO *keptncommon.ServiceLevelObjectives, sliResults []*keptnv2.SLIResult, writeMode string) error {
+	if writeMode == common.DynatraceConfigWriteModeNEVER {
+		log.Debug("Not storing the generated sli.yaml and slo.yaml as writeMode is never")
+	}
+
 	// lets write the SLI to the config repo
-	if dashboardSLI != nil {
+	if dashboardSLI != nil && writeMode != common.DynatraceConfigWriteModeNEVER {
 		yamlAsByteArray, _ := yaml.Marshal(dashboardSLI)
 
-		err := common.UploadKeptnResource(yamlAsByteArray, common.DynatraceSLIFilename, keptnEvent)
+		err := uploadGeneratedResource(keptnEvent, yamlAsByteArray, common.DynatraceSLIFilename, writeMode, common.MergeGeneratedSLI)
 		if err != nil {
-			return dashboardLinkAsLabel, sliResults, fmt.Errorf("could not store %s : %v", common.DynatraceSLIFilename, err)
+			return err
 		}
 	}
 
 	// lets write the SLO to the config repo
-	if dashboardSLO != nil {
+	if dashboardSLO != nil && writeMode != common.DynatraceConfigWriteModeNEVER {
 		yamlAsByteArray, _ := yaml.Marshal(dashboardSLO)
 
-		err := common.UploadKeptnResource(yamlAsByteArray, common.KeptnSLOFilename, keptnEvent)
+		err := uploadGeneratedResource(keptnEvent, yamlAsByteArray, common.KeptnSLOFilename, writeMode, common.MergeGeneratedSLO)
 		if err != nil {
-			return dashboardLinkAsLabel, sliResults, fmt.Errorf("could not store %s : %v", common.KeptnSLOFilename, err)
+			return err
 		}
 	}
 
@@ -251,7 +291,47 @@ func getDataFromDynatraceDashboard(dynatraceHandler *dynatrace.Handler, keptnEve
 		}
 	}
 
-	return dashboardLinkAsLabel, sliResults, nil
+	return nil
+}
+
+/**
+ * Uploads a generated resource. With writeMode merge it is merged into the resource that exists on service level
+ */
+func uploadGeneratedResource(keptnEvent *common.BaseKeptnEvent, generatedContent []byte, resourceURI string, writeMode string, merge func(string, []byte) ([]byte, error)) error {
+	if writeMode == common.DynatraceConfigWriteModeMERGE {
+		// a cached version could miss changes the user just pushed - and - the merge would overwrite them
+		common.InvalidateCachedKeptnResources(keptnEvent)
+		existingContent, err := common.GetKeptnResourceOnConfigLevel(keptnEvent, resourceURI, common.ConfigLevelService)
+		if err != nil && err != common.ErrResourceNotFound {
+			return fmt.Errorf("could not load %s for merging: %v", resourceURI, err)
+		}
+
+		generatedContent, err = merge(existingContent, generatedContent)
+		if err != nil {
+			return err
+		}
+	}
+
+	err := common.UploadKeptnResource(generatedContent, resourceURI, keptnEvent)
+	if err != nil {
+		return fmt.Errorf("could not store %s : %v", resourceURI, err)
+	}
+
+	return nil
+}
+
+/**
+ * Writes the parse reports of all dashboards of this evaluation to the config repo
+ */
+func uploadDashboardParseReports(keptnEvent *common.BaseKeptnEvent, parseReports []*dynatrace.DashboardParseReport) error {
+	jsonAsByteArray, _ := json.MarshalIndent(parseReports, "", "  ")
+
+	err := common.UploadKeptnResource(jsonAsByteArray, common.DynatraceDashboardReportFilename, keptnEvent)
+	if err != nil {
+		return fmt.Errorf("could not store %s : %v", common.DynatraceDashboardReportFilename, err)
+	}
+
+	return nil
 }
 
 /**
@@ -312,6 +392,9 @@ func retrieveMetrics(event cloudevents.Event, eventData *keptnv2.GetSLITriggered
 	keptnEvent.Deployment = eventData.Deployment
 	keptnEvent.Context = shkeptncontext
 
+	// dynatrace.conf.yaml and dashboard.json are needed by every evaluation - so - lets load all their config levels in parallel
+	common.PreloadKeptnResources(keptnEvent, common.DynatraceConfigFilename, common.DynatraceDashboardFilename)
+
 	dynatraceConfigFile := common.GetDynatraceConfig(keptnEvent)
 
 	// Adding DtCreds as a label so users know which DtCreds was used
@@ -337,6 +420,9 @@ func retrieveMetrics(event cloudevents.Event, eventData *keptnv2.GetSLITriggered
 			"User-Agent":    "keptn-contrib/dynatrace-sli-service:" + os.Getenv("version"),
 		},
 		eventData.GetSLI.CustomFilters, shkeptncontext, event.ID())
+	dynatraceHandler.EntitySelector = dynatraceConfigFile.EntitySelector
+	dynatraceHandler.DashboardOwner = dynatraceConfigFile.DashboardOwner
+	dynatraceHandler.DashboardSharedOnly = dynatraceConfigFile.DashboardSharedOnly
 
 	//
 	// parse start and end (which are datetime strings) and convert them into unix timestamps
@@ -353,18 +439,73 @@ func retrieveMetrics(event cloudevents.Event, eventData *keptnv2.GetSLITriggered
 
 	//
 	// Option 1 - see if we can get the data from a Dnatrace Dashboard
-	dashboardLinkAsLabel, sliResults, err := getDataFromDynatraceDashboard(dynatraceHandler, keptnEvent, startUnix, endUnix, dynatraceConfigFile.Dashboard)
-	if err != nil {
-		// log the error, but continue with loading sli.yaml
-		log.WithError(err).Error("getDataFromDynatraceDashboard failed")
+	var dashboardLinks []string
+	if mergedDashboards := dynatraceConfigFile.GetMergedDashboards(); mergedDashboards != nil {
+		// multiple dashboards are explicitly configured - so - we dont fall back to sli.yaml if they cant be merged
+		dashboardLinks, sliResults, err = getDataFromDynatraceDashboards(dynatraceHandler, keptnEvent, startUnix, endUnix, mergedDashboards, dynatraceConfigFile.GetWriteMode())
+		if err != nil {
+			log.WithError(err).Error("getDataFromDynatraceDashboards failed")
+			return sendGetSLIFinishedEvent(event, eventData, nil, err)
+		}
+	} else {
+		var dashboardLinkAsLabel string
+		dashboardLinkAsLabel, sliResults, err = getDataFromDynatraceDashboard(dynatraceHandler, keptnEvent, startUnix, endUnix, dynatraceConfigFile.Dashboard, dynatraceConfigFile.GetWriteMode())
+		if err != nil {
+			// log the error, but continue with loading sli.yaml
+			log.WithError(err).Error("getDataFromDynatraceDashboard failed")
+		}
+		if dashboardLinkAsLabel != "" {
+			dashboardLinks = append(dashboardLinks, dashboardLinkAsLabel)
+		}
 	}
 
-	// add link to dynatrace dashboard to labels
-	if dashboardLinkAsLabel != "" {
+	// add links to dynatrace dashboards to labels
+	for i, dashboardLink := range dashboardLinks {
 		if eventData.Labels == nil {
 			eventData.Labels = make(map[string]string)
 		}
-		eventData.Labels["Dashboard Link"] = dashboardLinkAsLabel
+		if i == 0 {
+			eventData.Labels["Dashboard Link"] = dashboardLink
+		} else {
+			eventData.Labels[fmt.Sprintf("Dashboard Link %d", i+1)] = dashboardLink
+		}
+	}
+
+	// record whether the dashboard was parsed or the sli.yaml & slo.yaml generated from the unchanged dashboard are used
+	if len(dashboardLinks) > 0 {
+		eventData.Labels["Dashboard Reparsed"] = strconv.FormatBool(dynatraceHandler.DashboardReparsed)
+	}
+
+	// report problems of the dashboard configuration, e.g: invalid KQG settings in a markdown, so the dashboard author sees them
+	if len(dynatraceHandler.DashboardProblems) > 0 {
+		eventData.Labels["Dashboard Problems"] = strings.Join(dynatraceHandler.DashboardProblems, "; ")
+	}
+
+	// store which tiles became SLIs - and why the others didnt - next to dashboard.json and link it
+	if len(dynatraceHandler.DashboardParseReports) > 0 {
+		if err := uploadDashboardParseReports(keptnEvent, dynatraceHandler.DashboardParseReports); err != nil {
+			log.WithError(err).Error("uploadDashboardParseReports failed")
+		} else {
+			eventData.Labels["Dashboard Parse Report"] = common.GetKeptnResourceLink(keptnEvent, common.DynatraceDashboardReportFilename)
+		}
+	}
+
+	//
+	// With writeMode merge the sli.yaml & slo.yaml also keep the user-authored indicators & objectives. A reparsed dashboard only returns results for its own indicators - so - we query the others based on the merged sli.yaml
+	if sliResults != nil && dynatraceConfigFile.GetWriteMode() == common.DynatraceConfigWriteModeMERGE {
+		projectCustomQueries, _ := common.GetCustomQueries(keptnEvent)
+		if projectCustomQueries != nil {
+			dynatraceHandler.CustomQueries = projectCustomQueries
+		}
+
+		var indicators []string
+		for _, indicator := range eventData.GetSLI.Indicators {
+			// problem_open is handled later
+			if indicator != ProblemOpenSLI {
+				indicators = append(indicators, indicator)
+			}
+		}
+		sliResults = dynatraceHandler.AddMissingSLIResults(indicators, sliResults, startUnix, endUnix)
 	}
 
 	//
@@ -595,9 +736,14 @@ func sendGetSLIStartedEvent(inputEvent cloudevents.Event, eventData *keptnv2.Get
 
 /**
  * sends cloud event back to keptn
+ * On a remote execution plane the event is sent through the Keptn API, otherwise through the distributor
  */
 func sendEvent(event cloudevents.Event) error {
 
+	if keptnAPIConfig := common.GetKeptnAPIConfig(); keptnAPIConfig != nil {
+		return sendEventToKeptnAPI(keptnAPIConfig, event)
+	}
+
 	keptnHandler, err := keptnv2.NewKeptn(&event, keptn.KeptnOpts{})
 	if err != nil {
 		return err
@@ -607,3 +753,42 @@ func sendEvent(event cloudevents.Event) error {
 
 	return nil
 }
+
+/**
+ * sends cloud event to the /v1/event endpoint of the Keptn API
+ */
+func sendEventToKeptnAPI(keptnAPIConfig *common.KeptnAPIConfig, event cloudevents.Event) error {
+	apiHandler := keptnapi.NewAuthenticatedAPIHandler(keptnAPIConfig.BaseURL, keptnAPIConfig.Token, common.KeptnAPITokenHeader, nil, keptnAPIConfig.Scheme)
+	apiHandler.HTTPClient.Transport = keptnAPIConfig.NewTransport()
+
+	var shkeptncontext string
+	event.Context.ExtensionAs("shkeptncontext", &shkeptncontext)
+	var triggeredID string
+	event.Context.ExtensionAs("triggeredid", &triggeredID)
+
+	source := event.Source()
+	eventType := event.Type()
+	keptnEvent := keptnmodels.KeptnContextExtendedCE{
+		Contenttype:        event.DataContentType(),
+		Data:               json.RawMessage(event.Data()),
+		ID:                 event.ID(),
+		Shkeptncontext:     shkeptncontext,
+		Shkeptnspecversion: keptnSpecVersion,
+		Source:             &source,
+		Specversion:        event.SpecVersion(),
+		Time:               event.Time(),
+		Triggeredid:        triggeredID,
+		Type:               &eventType,
+	}
+
+	_, errorObj := apiHandler.SendEvent(keptnEvent)
+	if errorObj != nil {
+		message := ""
+		if errorObj.Message != nil {
+			message = *errorObj.Message
+		}
+		return fmt.Errorf("could not send event to Keptn API: %s", message)
+	}
+
+	return nil
+}
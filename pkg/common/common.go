@@ -9,6 +9,7 @@ import (
 	"os"
 	"strconv"
 	"strings"
+	"sync"
 	"time"
 
 	"gopkg.in/yaml.v2"
@@ -17,8 +18,6 @@ import (
 
 	log "github.com/sirupsen/logrus"
 
-	keptnmodels "github.com/keptn/go-utils/pkg/api/models"
-	keptnapi "github.com/keptn/go-utils/pkg/api/utils"
 	keptncommon "github.com/keptn/go-utils/pkg/lib"
 	"github.com/keptn/go-utils/pkg/lib/keptn"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
@@ -31,6 +30,7 @@ var RunLocalTest = (os.Getenv("ENV") == "localtest")
  * Constants for supporting resource files in keptn repo
  */
 const DynatraceDashboardFilename = "dynatrace/dashboard.json"
+const DynatraceDashboardReportFilename = "dynatrace/dashboard-report.json"
 const DynatraceSLIFilename = "dynatrace/sli.yaml"
 const KeptnSLOFilename = "slo.yaml"
 
@@ -44,11 +44,94 @@ const ConfigLevelService = "Service"
 const DynatraceConfigFilename = "dynatrace/dynatrace.conf.yaml"
 const DynatraceConfigFilenameLOCAL = "dynatrace/_dynatrace.conf.yaml"
 const DynatraceConfigDashboardQUERY = "query"
+const DynatraceConfigDashboardREPO = "repo"
+
+/**
+ * Prefixes for selecting a dashboard by name, e.g: name:Quality Gate - $SERVICE ($STAGE) or nameRegex:^KQG $SERVICE.*$
+ * Keptn placeholders such as $PROJECT, $STAGE or $SERVICE are replaced before the dashboards are matched
+ */
+const DynatraceConfigDashboardNAME = "name:"
+const DynatraceConfigDashboardNAMEREGEX = "nameRegex:"
+
+/**
+ * Values of dynatrace.conf.yaml:writeMode - how the sli.yaml & slo.yaml generated from dashboards are written to the config repo
+ * overwrite: replaces the files (default), merge: only adds & updates what the dashboards generated, never: doesnt write them
+ */
+const DynatraceConfigWriteModeOVERWRITE = "overwrite"
+const DynatraceConfigWriteModeMERGE = "merge"
+const DynatraceConfigWriteModeNEVER = "never"
+
+/**
+ * Presets for the entitySelector that is used by the built-in SLIs (throughput, error_rate, response_time_pXX)
+ * management_zone relies on a naming convention: one management zone per project & stage named "Keptn: <project> <stage>" and services named like the Keptn service
+ * Any other value of dynatrace.conf.yaml:entitySelector is used as a custom template, e.g: for management zones with other names
+ */
+const DynatraceConfigEntitySelectorTAGS = "tags"
+const DynatraceConfigEntitySelectorMZ = "management_zone"
+const DynatraceConfigEntitySelectorK8S = "k8s_workload"
+
+var entitySelectorPresets = map[string]string{
+	DynatraceConfigEntitySelectorTAGS: "type(SERVICE),tag(keptn_project:$PROJECT),tag(keptn_stage:$STAGE),tag(keptn_service:$SERVICE),tag(keptn_deployment:$DEPLOYMENT)",
+	DynatraceConfigEntitySelectorMZ:   "type(SERVICE),mzName(Keptn: $PROJECT $STAGE),entityName($SERVICE)",
+	DynatraceConfigEntitySelectorK8S:  "type(SERVICE),toRelationships.isNamespaceOfService(type(CLOUD_APPLICATION_NAMESPACE),entityName($PROJECT-$STAGE)),toRelationships.isCgiOfService(type(CLOUD_APPLICATION),entityName($SERVICE))",
+}
 
 type DynatraceConfigFile struct {
-	SpecVersion string `json:"spec_version" yaml:"spec_version"`
-	DtCreds     string `json:"dtCreds,omitempty" yaml:"dtCreds,omitempty"`
-	Dashboard   string `json:"dashboard,omitempty" yaml:"dashboard,omitempty"`
+	SpecVersion    string `json:"spec_version" yaml:"spec_version"`
+	DtCreds        string `json:"dtCreds,omitempty" yaml:"dtCreds,omitempty"`
+	Dashboard      string `json:"dashboard,omitempty" yaml:"dashboard,omitempty"`
+	EntitySelector string `json:"entitySelector,omitempty" yaml:"entitySelector,omitempty"`
+
+	// Dashboards allows to merge SLIs & SLOs of multiple dashboards - each with an optional indicator prefix
+	Dashboards []DynatraceConfigDashboard `json:"dashboards,omitempty" yaml:"dashboards,omitempty"`
+
+	// DashboardOwner & DashboardSharedOnly narrow down the dashboards considered for dashboard: name:xxx or nameRegex:xxx
+	DashboardOwner      string `json:"dashboardOwner,omitempty" yaml:"dashboardOwner,omitempty"`
+	DashboardSharedOnly bool   `json:"dashboardSharedOnly,omitempty" yaml:"dashboardSharedOnly,omitempty"`
+
+	// WriteMode defines how the sli.yaml & slo.yaml generated from dashboards are written: overwrite, merge or never
+	WriteMode string `json:"writeMode,omitempty" yaml:"writeMode,omitempty"`
+}
+
+// DynatraceConfigDashboard is a single entry of dynatrace.conf.yaml:dashboards
+// Dashboard supports the same values as dynatrace.conf.yaml:dashboard, Prefix is put in front of every indicator name of that dashboard
+type DynatraceConfigDashboard struct {
+	Dashboard string `json:"dashboard" yaml:"dashboard"`
+	Prefix    string `json:"prefix,omitempty" yaml:"prefix,omitempty"`
+}
+
+/**
+ * Returns all dashboards that should be merged: dynatrace.conf.yaml:dashboard (if set) followed by dynatrace.conf.yaml:dashboards
+ * Returns nil if dynatrace.conf.yaml:dashboards is not used
+ */
+func (c DynatraceConfigFile) GetMergedDashboards() []DynatraceConfigDashboard {
+	if len(c.Dashboards) == 0 {
+		return nil
+	}
+
+	var dashboards []DynatraceConfigDashboard
+	if c.Dashboard != "" {
+		dashboards = append(dashboards, DynatraceConfigDashboard{Dashboard: c.Dashboard})
+	}
+	return append(dashboards, c.Dashboards...)
+}
+
+/**
+ * Returns dynatrace.conf.yaml:writeMode in lower case. Defaults to overwrite if it is empty
+ * An unknown value, e.g: a typo like nevr, becomes never - we rather dont write the files than overwrite files the user wanted to keep
+ */
+func (c DynatraceConfigFile) GetWriteMode() string {
+	switch strings.ToLower(c.WriteMode) {
+	case "", DynatraceConfigWriteModeOVERWRITE:
+		return DynatraceConfigWriteModeOVERWRITE
+	case DynatraceConfigWriteModeMERGE:
+		return DynatraceConfigWriteModeMERGE
+	case DynatraceConfigWriteModeNEVER:
+		return DynatraceConfigWriteModeNEVER
+	default:
+		log.WithField("writeMode", c.WriteMode).Error("Unknown writeMode, generated sli.yaml & slo.yaml are not written")
+		return DynatraceConfigWriteModeNEVER
+	}
 }
 
 type DTCredentials struct {
@@ -108,29 +191,36 @@ func GetKubernetesClient() (*kubernetes.Clientset, error) {
 // $SECRET.YYYY -> will replace that with the k8s secret called YYYY
 //
 func ReplaceKeptnPlaceholders(input string, keptnEvent *BaseKeptnEvent) string {
-	result := input
-
 	// FIXING on 27.5.2020: URL Escaping of parameters as described in https://github.com/keptn-contrib/dynatrace-sli-service/issues/54
+	return ReplaceKeptnPlaceholdersWithEscaping(input, keptnEvent, url.QueryEscape)
+}
+
+//
+// same as ReplaceKeptnPlaceholders but every value is escaped with the passed function instead of url.QueryEscape
+// e.g: regexp.QuoteMeta when the result is used as regular expression
+//
+func ReplaceKeptnPlaceholdersWithEscaping(input string, keptnEvent *BaseKeptnEvent, escape func(string) string) string {
+	result := input
 
 	// first we do the regular keptn values
-	result = strings.Replace(result, "$CONTEXT", url.QueryEscape(keptnEvent.Context), -1)
-	result = strings.Replace(result, "$EVENT", url.QueryEscape(keptnEvent.Event), -1)
-	result = strings.Replace(result, "$SOURCE", url.QueryEscape(keptnEvent.Source), -1)
-	result = strings.Replace(result, "$PROJECT", url.QueryEscape(keptnEvent.Project), -1)
-	result = strings.Replace(result, "$STAGE", url.QueryEscape(keptnEvent.Stage), -1)
-	result = strings.Replace(result, "$SERVICE", url.QueryEscape(keptnEvent.Service), -1)
-	result = strings.Replace(result, "$DEPLOYMENT", url.QueryEscape(keptnEvent.Deployment), -1)
-	result = strings.Replace(result, "$TESTSTRATEGY", url.QueryEscape(keptnEvent.TestStrategy), -1)
+	result = strings.Replace(result, "$CONTEXT", escape(keptnEvent.Context), -1)
+	result = strings.Replace(result, "$EVENT", escape(keptnEvent.Event), -1)
+	result = strings.Replace(result, "$SOURCE", escape(keptnEvent.Source), -1)
+	result = strings.Replace(result, "$PROJECT", escape(keptnEvent.Project), -1)
+	result = strings.Replace(result, "$STAGE", escape(keptnEvent.Stage), -1)
+	result = strings.Replace(result, "$SERVICE", escape(keptnEvent.Service), -1)
+	result = strings.Replace(result, "$DEPLOYMENT", escape(keptnEvent.Deployment), -1)
+	result = strings.Replace(result, "$TESTSTRATEGY", escape(keptnEvent.TestStrategy), -1)
 
 	// now we do the labels
 	for key, value := range keptnEvent.Labels {
-		result = strings.Replace(result, "$LABEL."+key, url.QueryEscape(value), -1)
+		result = strings.Replace(result, "$LABEL."+key, escape(value), -1)
 	}
 
 	// now we do all environment variables
 	for _, env := range os.Environ() {
 		pair := strings.SplitN(env, "=", 2)
-		result = strings.Replace(result, "$ENV."+pair[0], url.QueryEscape(pair[1]), -1)
+		result = strings.Replace(result, "$ENV."+pair[0], escape(pair[1]), -1)
 	}
 
 	// TODO: iterate through k8s secrets!
@@ -147,121 +237,77 @@ func GetConfigurationServiceURL() string {
 
 //
 // Downloads a resource from the Keptn Configuration Repo based on the level (Project, Stage, Service)
-// In RunLocal mode it gets it from the local disk
+// The resource is read through the configured ResourceStore, e.g: configuration-service, local directory or git repository
 //
 func GetKeptnResourceOnConfigLevel(keptnEvent *BaseKeptnEvent, resourceURI string, level string) (string, error) {
+	fileContent, _, err := resourceStore.GetResource(keptnEvent, resourceURI, level)
+	if err != nil {
+		return "", err
+	}
 
-	// if we run in a runlocal mode we are just getting the file from the local disk
-	var fileContent string
-	if RunLocal {
-		resourceURI = strings.ToLower(strings.ReplaceAll(resourceURI, "dynatrace/", "../../../dynatrace/"+level+"_"))
-		localFileContent, err := ioutil.ReadFile(resourceURI)
-		if err != nil {
-			log.WithFields(
-				log.Fields{
-					"resourceURI": resourceURI,
-					"service":     keptnEvent.Service,
-					"stage":       keptnEvent.Stage,
-					"project":     keptnEvent.Project,
-				}).Info("File not found locally")
-			return "", nil
-		}
-		log.WithField("resourceURI", resourceURI).Info("Loaded LOCAL file")
-		fileContent = string(localFileContent)
-	} else {
-		resourceHandler := keptnapi.NewResourceHandler(GetConfigurationServiceURL())
-
-		var keptnResourceContent *keptnmodels.Resource
-		var err error
-		if strings.Compare(level, ConfigLevelProject) == 0 {
-			keptnResourceContent, err = resourceHandler.GetProjectResource(keptnEvent.Project, resourceURI)
-		} else if strings.Compare(level, ConfigLevelStage) == 0 {
-			keptnResourceContent, err = resourceHandler.GetStageResource(keptnEvent.Project, keptnEvent.Stage, resourceURI)
-		} else if strings.Compare(level, ConfigLevelService) == 0 {
-			keptnResourceContent, err = resourceHandler.GetServiceResource(keptnEvent.Project, keptnEvent.Stage, keptnEvent.Service, resourceURI)
-		} else {
-			return "", errors.New("Config level not valid: " + level)
-		}
-
-		if err != nil {
-			return "", err
-		}
+	return fileContent, nil
+}
 
-		if keptnResourceContent == nil {
-			return "", errors.New("Found resource " + resourceURI + " on level " + level + " but didnt contain content")
+/**
+ * Loads the passed resources on all config levels in parallel so that subsequent calls to GetKeptnResource & GetKeptnResourceOnConfigLevel are served from the cache
+ */
+func PreloadKeptnResources(keptnEvent *BaseKeptnEvent, resourceURIs ...string) {
+	var wg sync.WaitGroup
+	for _, resourceURI := range resourceURIs {
+		for _, level := range []string{ConfigLevelService, ConfigLevelStage, ConfigLevelProject} {
+			wg.Add(1)
+			go func(resourceURI string, level string) {
+				defer wg.Done()
+				resourceStore.GetResource(keptnEvent, resourceURI, level)
+			}(resourceURI, level)
 		}
-
-		fileContent = keptnResourceContent.ResourceContent
 	}
-
-	return fileContent, nil
+	wg.Wait()
 }
 
 //
 // Downloads a resource from the Keptn Configuration Repo
-// In RunLocal mode it gets it from the local disk
-// In normal mode it first tries to find it on service level, then stage and then project level
+// It first tries to find it on service level, then stage and then project level
 //
 func GetKeptnResource(keptnEvent *BaseKeptnEvent, resourceURI string) (string, error) {
 
-	// if we run in a runlocal mode we are just getting the file from the local disk
-	var fileContent string
-	if RunLocal {
-		localFileContent, err := ioutil.ReadFile(resourceURI)
-		if err != nil {
+	// Lets search on SERVICE-LEVEL
+	keptnResourceContent, err := GetKeptnResourceOnConfigLevel(keptnEvent, resourceURI, ConfigLevelService)
+	if err != nil || keptnResourceContent == "" {
+		// Lets search on STAGE-LEVEL
+		keptnResourceContent, err = GetKeptnResourceOnConfigLevel(keptnEvent, resourceURI, ConfigLevelStage)
+		if err != nil || keptnResourceContent == "" {
+			// Lets search on PROJECT-LEVEL
+			keptnResourceContent, err = GetKeptnResourceOnConfigLevel(keptnEvent, resourceURI, ConfigLevelProject)
+			if err != nil || keptnResourceContent == "" {
+				// log.Debugf("No Keptn Resource found: %s/%s/%s/%s - %s", keptnEvent.Project, keptnEvent.Stage, keptnEvent.Service, resourceURI, err)
+				return "", err
+			}
+
 			log.WithFields(
 				log.Fields{
 					"resourceURI": resourceURI,
-					"service":     keptnEvent.Service,
-					"stage":       keptnEvent.Stage,
 					"project":     keptnEvent.Project,
-				}).Info("File not found locally")
-			return "", nil
-		}
-		log.WithField("resourceURI", resourceURI).Info("Loaded LOCAL file")
-		fileContent = string(localFileContent)
-	} else {
-		resourceHandler := keptnapi.NewResourceHandler(GetConfigurationServiceURL())
-
-		// Lets search on SERVICE-LEVEL
-		keptnResourceContent, err := resourceHandler.GetServiceResource(keptnEvent.Project, keptnEvent.Stage, keptnEvent.Service, resourceURI)
-		if err != nil || keptnResourceContent == nil || keptnResourceContent.ResourceContent == "" {
-			// Lets search on STAGE-LEVEL
-			keptnResourceContent, err = resourceHandler.GetStageResource(keptnEvent.Project, keptnEvent.Stage, resourceURI)
-			if err != nil || keptnResourceContent == nil || keptnResourceContent.ResourceContent == "" {
-				// Lets search on PROJECT-LEVEL
-				keptnResourceContent, err = resourceHandler.GetProjectResource(keptnEvent.Project, resourceURI)
-				if err != nil || keptnResourceContent == nil || keptnResourceContent.ResourceContent == "" {
-					// log.Debugf("No Keptn Resource found: %s/%s/%s/%s - %s", keptnEvent.Project, keptnEvent.Stage, keptnEvent.Service, resourceURI, err)
-					return "", err
-				}
-
-				log.WithFields(
-					log.Fields{
-						"resourceURI": resourceURI,
-						"project":     keptnEvent.Project,
-					}).Debug("Found resource on project level")
-			} else {
-				log.WithFields(
-					log.Fields{
-						"resourceURI": resourceURI,
-						"project":     keptnEvent.Project,
-						"stage":       keptnEvent.Stage,
-					}).Debug("Found resource on stage level")
-			}
+				}).Debug("Found resource on project level")
 		} else {
 			log.WithFields(
 				log.Fields{
 					"resourceURI": resourceURI,
 					"project":     keptnEvent.Project,
 					"stage":       keptnEvent.Stage,
-					"service":     keptnEvent.Service,
-				}).Debug("Found resource on service level")
+				}).Debug("Found resource on stage level")
 		}
-		fileContent = keptnResourceContent.ResourceContent
+	} else {
+		log.WithFields(
+			log.Fields{
+				"resourceURI": resourceURI,
+				"project":     keptnEvent.Project,
+				"stage":       keptnEvent.Stage,
+				"service":     keptnEvent.Service,
+			}).Debug("Found resource on service level")
 	}
 
-	return fileContent, nil
+	return keptnResourceContent, nil
 }
 
 /**
@@ -307,27 +353,30 @@ func GetCustomQueries(keptnEvent *BaseKeptnEvent) (map[string]string, error) {
 	// We need to load sli.yaml in the sequence of project, stage then service level where service level will overwrite stage & project and stage will overwrite project level sli defintions
 	// details can be found here: https://github.com/keptn-contrib/dynatrace-sli-service/issues/112
 
-	// Step 1: Load Project Level
-	foundLocation := ""
-	sliContent, err := GetKeptnResourceOnConfigLevel(keptnEvent, DynatraceSLIFilename, ConfigLevelProject)
-	if err == nil && sliContent != "" {
-		sliMap, _ = AddResourceContentToSLIMap(sliMap, "", sliContent)
-		foundLocation = "project,"
-	}
-
-	// Step 2: Load Stage Level
-	sliContent, err = GetKeptnResourceOnConfigLevel(keptnEvent, DynatraceSLIFilename, ConfigLevelStage)
-	if err == nil && sliContent != "" {
-		sliMap, _ = AddResourceContentToSLIMap(sliMap, "", sliContent)
-		foundLocation = foundLocation + "stage,"
+	// All three levels are independent - so - we load them in parallel but merge them in the order project, stage, service
+	levels := []string{ConfigLevelProject, ConfigLevelStage, ConfigLevelService}
+	sliContents := make([]string, len(levels))
+	var wg sync.WaitGroup
+	for ix, level := range levels {
+		wg.Add(1)
+		go func(ix int, level string) {
+			defer wg.Done()
+			sliContent, err := GetKeptnResourceOnConfigLevel(keptnEvent, DynatraceSLIFilename, level)
+			if err == nil {
+				sliContents[ix] = sliContent
+			}
+		}(ix, level)
 	}
+	wg.Wait()
 
-	// Step 3: Load Service Level
-	sliContent, err = GetKeptnResourceOnConfigLevel(keptnEvent, DynatraceSLIFilename, ConfigLevelService)
-	if err == nil && sliContent != "" {
-		sliMap, _ = AddResourceContentToSLIMap(sliMap, "", sliContent)
-		foundLocation = foundLocation + "service"
+	foundLocations := []string{}
+	for ix, sliContent := range sliContents {
+		if sliContent != "" {
+			sliMap, _ = AddResourceContentToSLIMap(sliMap, "", sliContent)
+			foundLocations = append(foundLocations, strings.ToLower(levels[ix]))
+		}
 	}
+	foundLocation := strings.Join(foundLocations, ",")
 
 	// couldnt load any SLIs
 	if len(sliMap) == 0 {
@@ -363,6 +412,21 @@ func GetDynatraceConfig(keptnEvent *BaseKeptnEvent) DynatraceConfigFile {
 	return dynatraceConfFile
 }
 
+// GetEntitySelectorTemplate returns the entitySelector template for the built-in SLIs
+// entitySelector can either be one of the presets (tags, management_zone, k8s_workload) or a custom template, e.g: type(SERVICE),tag(app:$SERVICE)
+// If entitySelector is empty the tags preset is returned
+func GetEntitySelectorTemplate(entitySelector string) string {
+	if entitySelector == "" {
+		entitySelector = DynatraceConfigEntitySelectorTAGS
+	}
+
+	if preset, ok := entitySelectorPresets[strings.ToLower(entitySelector)]; ok {
+		return preset
+	}
+
+	return entitySelector
+}
+
 func getBaseDynatraceConfig(keptnEvent *BaseKeptnEvent) DynatraceConfigFile {
 
 	var defaultDynatraceConfigFile = DynatraceConfigFile{
@@ -395,30 +459,28 @@ func getBaseDynatraceConfig(keptnEvent *BaseKeptnEvent) DynatraceConfigFile {
 	return dynatraceConfFile
 }
 
-// UploadKeptnResource uploads a file to the Keptn Configuration Service
+// UploadKeptnResource uploads a file to the Keptn Configuration Repo through the configured ResourceStore
 func UploadKeptnResource(contentToUpload []byte, remoteResourceURI string, keptnEvent *BaseKeptnEvent) error {
+	err := resourceStore.UploadResource(keptnEvent, remoteResourceURI, contentToUpload)
+	if err != nil {
+		return err
+	}
 
-	// if we run in a runlocal mode we are just getting the file from the local disk
-	if RunLocal || RunLocalTest {
-		err := ioutil.WriteFile(remoteResourceURI, contentToUpload, 0644)
-		if err != nil {
-			return fmt.Errorf("Couldnt write local file %s: %v", remoteResourceURI, err)
-		}
-		log.WithField("remoteResourceURI", remoteResourceURI).Info("Local file written")
-	} else {
-		resourceHandler := keptnapi.NewResourceHandler(GetConfigurationServiceURL())
-
-		// lets upload it
-		resources := []*keptnmodels.Resource{{ResourceContent: string(contentToUpload), ResourceURI: &remoteResourceURI}}
-		_, err := resourceHandler.CreateResources(keptnEvent.Project, keptnEvent.Stage, keptnEvent.Service, resources)
-		if err != nil {
-			return fmt.Errorf("Couldnt upload remote resource %s: %s", remoteResourceURI, *err.Message)
-		}
+	log.WithField("remoteResourceURI", remoteResourceURI).Info("Uploaded file")
+	return nil
+}
 
-		log.WithField("remoteResourceURI", remoteResourceURI).Info("Uploaded file")
+/**
+ * Returns a link to a resource of the service that can be used in a label, e.g: https://keptn.mycompany.com/api/configuration-service/v1/project/sockshop/stage/staging/service/carts/resource/dynatrace%2Fdashboard-report.json
+ * Without KEPTN_API_URL there is no public URL - so - we return the location of the resource in the config repo, e.g: sockshop/staging/carts/dynatrace/dashboard-report.json
+ */
+func GetKeptnResourceLink(keptnEvent *BaseKeptnEvent, resourceURI string) string {
+	keptnAPIURL := strings.TrimSuffix(os.Getenv("KEPTN_API_URL"), "/")
+	if keptnAPIURL == "" {
+		return fmt.Sprintf("%s/%s/%s/%s", keptnEvent.Project, keptnEvent.Stage, keptnEvent.Service, resourceURI)
 	}
 
-	return nil
+	return fmt.Sprintf("%s/configuration-service/v1/project/%s/stage/%s/service/%s/resource/%s", keptnAPIURL, keptnEvent.Project, keptnEvent.Stage, keptnEvent.Service, url.PathEscape(resourceURI))
 }
 
 /**
@@ -464,9 +526,7 @@ func GetDTCredentials(dynatraceSecretName string) (*DTCredentials, error) {
 	}
 
 	// ensure URL always has http or https in front
-	if strings.HasPrefix(dtCreds.Tenant, "https://") || strings.HasPrefix(dtCreds.Tenant, "http://") {
-		dtCreds.Tenant = dtCreds.Tenant
-	} else {
+	if !strings.HasPrefix(dtCreds.Tenant, "https://") && !strings.HasPrefix(dtCreds.Tenant, "http://") {
 		dtCreds.Tenant = "https://" + dtCreds.Tenant
 	}
 
@@ -568,52 +628,72 @@ func ParsePassAndWarningFromString(customName string, defaultPass []string, defa
 	return sliName, passCriteria, warnCriteria, weight, keySli
 }
 
-// ParseMarkdownConfiguration parses a text that can be used in a Markdown tile to specify global SLO properties
-func ParseMarkdownConfiguration(markdown string, slo *keptncommon.ServiceLevelObjectives) {
-	markdownSplits := strings.Split(markdown, ";")
+// SectionHeaderPrefix marks a HEADER tile whose name defines defaults for all tiles of its section, e.g: KQG.Section;prefix=perf_;weight=2;key=true
+const SectionHeaderPrefix = "KQG.Section"
 
-	for _, markdownSplitValue := range markdownSplits {
-		configValueSplits := strings.Split(markdownSplitValue, "=")
-		if len(configValueSplits) != 2 {
-			continue
+// ParseSectionHeader returns the section defaults of a HEADER tile name and whether the name defines a section
+func ParseSectionHeader(headerName string) (string, bool) {
+	if !strings.HasPrefix(strings.ToLower(strings.TrimSpace(headerName)), strings.ToLower(SectionHeaderPrefix)) {
+		return "", false
+	}
+	return strings.TrimSpace(headerName)[len(SectionHeaderPrefix):], true
+}
+
+// ApplySectionDefaults applies the defaults of a section to the name of a tile in that section
+// prefix is prepended to the sli name, all other values, e.g: pass, warning, weight, key, are added unless the tile name specifies them itself
+// Example: Response time;sli=rt;pass=<500 in the section ;prefix=perf_;weight=2;pass=<1000 becomes Response time;sli=perf_rt;pass=<500;weight=2
+func ApplySectionDefaults(tileName string, section string) string {
+	if section == "" {
+		return tileName
+	}
+
+	// lets first see which values the tile specifies itself
+	tileValues := map[string]bool{}
+	for _, nameValue := range strings.Split(tileName, ";") {
+		if nameValueDividerIndex := strings.Index(nameValue, "="); nameValueDividerIndex >= 0 {
+			tileValues[strings.ToLower(nameValue[:nameValueDividerIndex])] = true
 		}
+	}
 
-		// lets get configname and value
-		configName := strings.ToLower(configValueSplits[0])
-		configValue := configValueSplits[1]
-
-		switch configName {
-		case "kqg.total.pass":
-			slo.TotalScore.Pass = configValue
-		case "kqg.total.warning":
-			slo.TotalScore.Warning = configValue
-		case "kqg.compare.withscore":
-			slo.Comparison.IncludeResultWithScore = configValue
-			if (configValue == "pass") || (configValue == "pass_or_warn") || (configValue == "all") {
-				slo.Comparison.IncludeResultWithScore = configValue
-			} else {
-				slo.Comparison.IncludeResultWithScore = "pass"
-			}
-		case "kqg.compare.results":
-			noresults, err := strconv.Atoi(configValue)
-			if err != nil {
-				slo.Comparison.NumberOfComparisonResults = 1
-			} else {
-				slo.Comparison.NumberOfComparisonResults = noresults
-			}
-			if slo.Comparison.NumberOfComparisonResults > 1 {
-				slo.Comparison.CompareWith = "several_results"
-			} else {
-				slo.Comparison.CompareWith = "single_result"
-			}
-		case "kqg.compare.function":
-			if (configValue == "avg") || (configValue == "p50") || (configValue == "p90") || (configValue == "p95") {
-				slo.Comparison.AggregateFunction = configValue
-			} else {
-				slo.Comparison.AggregateFunction = "avg"
+	// a section doesnt include tiles - so - only tiles with an sli name get the defaults
+	if !tileValues["sli"] {
+		return tileName
+	}
+
+	nameValueSplits := strings.Split(tileName, ";")
+	for _, nameValue := range strings.Split(section, ";") {
+		nameValueDividerIndex := strings.Index(nameValue, "=")
+		if nameValueDividerIndex < 0 {
+			continue
+		}
+		name := strings.ToLower(nameValue[:nameValueDividerIndex])
+		value := nameValue[nameValueDividerIndex+1:]
+
+		switch {
+		case name == "prefix":
+			for i, tileNameValue := range nameValueSplits {
+				if strings.HasPrefix(strings.ToLower(tileNameValue), "sli=") {
+					nameValueSplits[i] = tileNameValue[:len("sli=")] + value + tileNameValue[len("sli="):]
+				}
 			}
+		case name == "sli" || tileValues[name]:
+			// the tile wins
+		default:
+			nameValueSplits = append(nameValueSplits, nameValue)
 		}
 	}
+
+	return strings.Join(nameValueSplits, ";")
+}
+
+// ParseMarkdownConfiguration parses a text that can be used in a Markdown tile to specify global SLO properties
+// Returns the problems found in the markdown - see MarkdownConfiguration.Parse for the grammar
+func ParseMarkdownConfiguration(markdown string, slo *keptncommon.ServiceLevelObjectives) []*MarkdownConfigurationError {
+	markdownConfig := &MarkdownConfiguration{}
+	errors := markdownConfig.Parse(markdown)
+	markdownConfig.ApplyToSLO(slo)
+
+	return errors
 }
 
 // cleanIndicatorName makes sure we have a valid indicator name by getting rid of special characters
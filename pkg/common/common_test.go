@@ -1,6 +1,7 @@
 package common
 
 import (
+	"os"
 	"reflect"
 	"testing"
 )
@@ -42,6 +43,19 @@ dashboard: dash`,
 			},
 			wantErr: false,
 		},
+		{
+			name: "valid yaml with entitySelector",
+			yamlString: `
+spec_version: '0.1.0'
+dtCreds: dyna
+entitySelector: k8s_workload`,
+			want: DynatraceConfigFile{
+				SpecVersion:    "0.1.0",
+				DtCreds:        "dyna",
+				EntitySelector: "k8s_workload",
+			},
+			wantErr: false,
+		},
 		{
 			name: "invalid yaml",
 			yamlString: `
@@ -51,6 +65,39 @@ dashboard: ****`,
 			want:    DynatraceConfigFile{},
 			wantErr: true,
 		},
+		{
+			name: "valid yaml with dashboard name and filters",
+			yamlString: `
+spec_version: '0.1.0'
+dashboard: 'name:Quality Gate - $SERVICE ($STAGE)'
+dashboardOwner: keptn
+dashboardSharedOnly: true`,
+			want: DynatraceConfigFile{
+				SpecVersion:         "0.1.0",
+				Dashboard:           "name:Quality Gate - $SERVICE ($STAGE)",
+				DashboardOwner:      "keptn",
+				DashboardSharedOnly: true,
+			},
+			wantErr: false,
+		},
+		{
+			name: "valid yaml with multiple dashboards",
+			yamlString: `
+spec_version: '0.1.0'
+dashboards:
+- dashboard: query
+  prefix: team_
+- dashboard: e6c947f2-4c29-483c-a065-269b3707bea4
+  prefix: platform_`,
+			want: DynatraceConfigFile{
+				SpecVersion: "0.1.0",
+				Dashboards: []DynatraceConfigDashboard{
+					{Dashboard: "query", Prefix: "team_"},
+					{Dashboard: "e6c947f2-4c29-483c-a065-269b3707bea4", Prefix: "platform_"},
+				},
+			},
+			wantErr: false,
+		},
 		{
 			name: "yaml with special characters",
 			yamlString: `
@@ -78,3 +125,74 @@ dashboard: '****'`,
 		})
 	}
 }
+
+func TestGetKeptnAPIConfig(t *testing.T) {
+	tests := []struct {
+		name      string
+		url       string
+		verifyTLS string
+		want      *KeptnAPIConfig
+	}{
+		{
+			name: "no api url",
+			url:  "",
+			want: nil,
+		},
+		{
+			name: "https api url",
+			url:  "https://keptn.mycompany.com/api/",
+			want: &KeptnAPIConfig{Scheme: "https", BaseURL: "keptn.mycompany.com/api", Token: "my-token", VerifyTLS: true},
+		},
+		{
+			name:      "http api url without tls verification",
+			url:       "http://keptn.mycompany.com:8080/api",
+			verifyTLS: "false",
+			want:      &KeptnAPIConfig{Scheme: "http", BaseURL: "keptn.mycompany.com:8080/api", Token: "my-token", VerifyTLS: false},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			os.Setenv("KEPTN_API_URL", tt.url)
+			os.Setenv("KEPTN_API_TOKEN", "my-token")
+			os.Setenv("KEPTN_API_VERIFY_TLS", tt.verifyTLS)
+			defer os.Unsetenv("KEPTN_API_URL")
+			defer os.Unsetenv("KEPTN_API_TOKEN")
+			defer os.Unsetenv("KEPTN_API_VERIFY_TLS")
+
+			got := GetKeptnAPIConfig()
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("GetKeptnAPIConfig() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestGetKeptnResourceLink(t *testing.T) {
+	keptnEvent := &BaseKeptnEvent{Project: "sockshop", Stage: "staging", Service: "carts"}
+
+	if got := GetKeptnResourceLink(keptnEvent, DynatraceDashboardReportFilename); got != "sockshop/staging/carts/dynatrace/dashboard-report.json" {
+		t.Errorf("GetKeptnResourceLink() without api url = %s", got)
+	}
+
+	os.Setenv("KEPTN_API_URL", "https://keptn.mycompany.com/api/")
+	defer os.Unsetenv("KEPTN_API_URL")
+	if got := GetKeptnResourceLink(keptnEvent, DynatraceDashboardReportFilename); got != "https://keptn.mycompany.com/api/configuration-service/v1/project/sockshop/stage/staging/service/carts/resource/dynatrace%2Fdashboard-report.json" {
+		t.Errorf("GetKeptnResourceLink() with api url = %s", got)
+	}
+}
+
+func TestGetMergedDashboards(t *testing.T) {
+	singleDashboard := DynatraceConfigFile{Dashboard: "query"}
+	if singleDashboard.GetMergedDashboards() != nil {
+		t.Errorf("GetMergedDashboards() should return nil if only dashboard is set")
+	}
+
+	multipleDashboards := DynatraceConfigFile{
+		Dashboard:  "query",
+		Dashboards: []DynatraceConfigDashboard{{Dashboard: "repo", Prefix: "platform_"}},
+	}
+	want := []DynatraceConfigDashboard{{Dashboard: "query"}, {Dashboard: "repo", Prefix: "platform_"}}
+	if got := multipleDashboards.GetMergedDashboards(); !reflect.DeepEqual(got, want) {
+		t.Errorf("GetMergedDashboards() = %v, want %v", got, want)
+	}
+}
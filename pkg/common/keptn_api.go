@@ -0,0 +1,59 @@
+package common
+
+import (
+	"crypto/tls"
+	"net/http"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// KeptnAPITokenHeader is the header the Keptn API gateway expects the API token in
+const KeptnAPITokenHeader = "x-token"
+
+// KeptnAPIConfig holds the connection details of the Keptn API that is used when running on a remote execution plane
+type KeptnAPIConfig struct {
+	// Scheme is either http or https
+	Scheme string
+	// BaseURL is the API base URL without scheme, e.g: keptn.mycompany.com/api
+	BaseURL   string
+	Token     string
+	VerifyTLS bool
+}
+
+/**
+ * Returns the Keptn API configuration based on KEPTN_API_URL, KEPTN_API_TOKEN & KEPTN_API_VERIFY_TLS
+ * Returns nil if KEPTN_API_URL is not set - in that case we talk to the configuration-service & distributor directly
+ */
+func GetKeptnAPIConfig() *KeptnAPIConfig {
+	apiURL := strings.TrimSuffix(os.Getenv("KEPTN_API_URL"), "/")
+	if apiURL == "" {
+		return nil
+	}
+
+	scheme := "https"
+	if parsedURL, err := url.Parse(apiURL); err == nil && parsedURL.Scheme != "" {
+		scheme = parsedURL.Scheme
+	}
+
+	verifyTLS := true
+	if b, err := strconv.ParseBool(os.Getenv("KEPTN_API_VERIFY_TLS")); err == nil {
+		verifyTLS = b
+	}
+
+	return &KeptnAPIConfig{
+		Scheme:    scheme,
+		BaseURL:   strings.TrimPrefix(strings.TrimPrefix(apiURL, "https://"), "http://"),
+		Token:     os.Getenv("KEPTN_API_TOKEN"),
+		VerifyTLS: verifyTLS,
+	}
+}
+
+// NewTransport returns an HTTP transport that honors the TLS verification setting and the proxy environment variables
+func (c *KeptnAPIConfig) NewTransport() *http.Transport {
+	return &http.Transport{
+		TLSClientConfig: &tls.Config{InsecureSkipVerify: !c.VerifyTLS},
+		Proxy:           http.ProxyFromEnvironment,
+	}
+}
@@ -0,0 +1,317 @@
+package common
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	keptncommon "github.com/keptn/go-utils/pkg/lib"
+)
+
+// MarkdownConfigPrefix starts every setting that can be put into a markdown tile, e.g: KQG.Total.Pass=90%
+const MarkdownConfigPrefix = "KQG."
+
+// QueryBehaviorParseOnChange only parses a dashboard if it changed since the last evaluation - this is the default
+const QueryBehaviorParseOnChange = "ParseOnChange"
+
+// QueryBehaviorAlways parses a dashboard on every evaluation
+const QueryBehaviorAlways = "Always"
+
+// IndicatorNameTemplateSLI & IndicatorNameTemplateTile are the placeholders of KQG.IndicatorName
+const IndicatorNameTemplateSLI = "$SLI"
+const IndicatorNameTemplateTile = "$TILE"
+
+// totalScoreExpression validates KQG.Total.Pass & KQG.Total.Warning, e.g: 90% or 90
+var totalScoreExpression = regexp.MustCompile(`^\d+(\.\d+)?%?$`)
+
+// criteriaExpression validates a single pass or warning criteria, e.g: <=500, <+10% or >-5
+var criteriaExpression = regexp.MustCompile(`^(<|<=|=|>|>=)[+-]?\d+(\.\d+)?%?$`)
+
+// indicatorNamePlaceholderExpression finds the placeholders used in KQG.IndicatorName
+var indicatorNamePlaceholderExpression = regexp.MustCompile(`\$[A-Za-z]+`)
+
+// MarkdownConfiguration holds all KQG settings specified in the markdown tiles or tags of a dashboard
+// Settings that are not specified keep their zero value
+type MarkdownConfiguration struct {
+	TotalPass        string
+	TotalWarning     string
+	CompareWithScore string
+	CompareResults   int
+	CompareFunction  string
+	QueryBehavior    string
+
+	// defaults for all tiles of the dashboard - a tile or its section can overwrite them
+	DefaultPass    []string
+	DefaultWarning []string
+	DefaultWeight  int
+	DefaultKey     *bool
+
+	// Exclude lists tile titles or sli names that are not evaluated even if they specify sli=
+	Exclude []string
+
+	// IndicatorName is the template for the indicator name of every tile, e.g: perf_$SLI or $TILE
+	IndicatorName string
+}
+
+// MarkdownConfigurationError is a problem found in a markdown tile. Line & Column are 1-based and point to the offending key or value
+type MarkdownConfigurationError struct {
+	Line    int
+	Column  int
+	Message string
+}
+
+func (e *MarkdownConfigurationError) Error() string {
+	return fmt.Sprintf("line %d, column %d: %s", e.Line, e.Column, e.Message)
+}
+
+/**
+ * Parse adds the KQG settings of a markdown to the configuration. Values of later markdowns overwrite earlier ones, KQG.Exclude adds up
+ * Markdown follows this grammar - everything that is not a setting is considered text of the markdown and ignored:
+ *
+ *   markdown  = entry { separator entry }
+ *   separator = ";" | newline
+ *   entry     = setting | text
+ *   setting   = "KQG." key "=" value      (key is case insensitive, whitespace around key and value is ignored)
+ *
+ * Unknown keys, missing or invalid values are returned as errors. Invalid comparison settings fall back to their default as they always did
+ */
+func (c *MarkdownConfiguration) Parse(markdown string) []*MarkdownConfigurationError {
+	var errors []*MarkdownConfigurationError
+
+	for lineIndex, line := range strings.Split(markdown, "\n") {
+		offset := 0
+		for _, entry := range strings.Split(line, ";") {
+			entryOffset := offset
+			offset += len(entry) + 1
+
+			trimmedEntry := strings.TrimSpace(entry)
+			if !strings.HasPrefix(strings.ToUpper(trimmedEntry), MarkdownConfigPrefix) {
+				continue
+			}
+
+			keyColumn := utf8.RuneCountInString(line[:entryOffset+strings.Index(entry, trimmedEntry)]) + 1
+			dividerIndex := strings.Index(trimmedEntry, "=")
+			if dividerIndex < 0 {
+				errors = append(errors, &MarkdownConfigurationError{Line: lineIndex + 1, Column: keyColumn, Message: fmt.Sprintf("%s is missing '=' and a value", trimmedEntry)})
+				continue
+			}
+
+			key := strings.TrimSpace(trimmedEntry[:dividerIndex])
+			rawValue := trimmedEntry[dividerIndex+1:]
+			value := strings.TrimSpace(rawValue)
+			valueColumn := keyColumn + utf8.RuneCountInString(trimmedEntry[:dividerIndex+1+len(rawValue)-len(strings.TrimLeft(rawValue, " \t"))])
+			if value == "" {
+				errors = append(errors, &MarkdownConfigurationError{Line: lineIndex + 1, Column: valueColumn, Message: fmt.Sprintf("%s has no value", key)})
+				continue
+			}
+
+			if message, isKeyError := c.setValue(key, value); message != "" {
+				column := valueColumn
+				if isKeyError {
+					column = keyColumn
+				}
+				errors = append(errors, &MarkdownConfigurationError{Line: lineIndex + 1, Column: column, Message: message})
+			}
+		}
+	}
+
+	return errors
+}
+
+/**
+ * ParseTags adds the KQG settings of dashboard tags to the configuration, e.g: kqg.total.pass:90% - tags follow the form "KQG." key ":" value
+ * Tags take the same settings as a markdown. As markdowns are parsed after the tags their settings win
+ */
+func (c *MarkdownConfiguration) ParseTags(tags []string) []error {
+	var errors []error
+
+	for _, tag := range tags {
+		if !strings.HasPrefix(strings.ToUpper(strings.TrimSpace(tag)), MarkdownConfigPrefix) {
+			continue
+		}
+
+		dividerIndex := strings.Index(tag, ":")
+		if dividerIndex < 0 || strings.TrimSpace(tag[dividerIndex+1:]) == "" {
+			errors = append(errors, fmt.Errorf("tag '%s' is missing ':' and a value", tag))
+			continue
+		}
+
+		if message, _ := c.setValue(strings.TrimSpace(tag[:dividerIndex]), strings.TrimSpace(tag[dividerIndex+1:])); message != "" {
+			errors = append(errors, fmt.Errorf("tag '%s': %s", tag, message))
+		}
+	}
+
+	return errors
+}
+
+/**
+ * setValue validates and sets a single setting. Returns a message if the setting is invalid and whether the key itself is the problem
+ */
+func (c *MarkdownConfiguration) setValue(key string, value string) (string, bool) {
+	switch strings.ToLower(key) {
+	case "kqg.total.pass":
+		if !totalScoreExpression.MatchString(value) {
+			return fmt.Sprintf("%s=%s is not a percentage, e.g: 90%%", key, value), false
+		}
+		c.TotalPass = value
+	case "kqg.total.warning":
+		if !totalScoreExpression.MatchString(value) {
+			return fmt.Sprintf("%s=%s is not a percentage, e.g: 75%%", key, value), false
+		}
+		c.TotalWarning = value
+	case "kqg.compare.withscore":
+		c.CompareWithScore = strings.ToLower(value)
+		if c.CompareWithScore != "pass" && c.CompareWithScore != "pass_or_warn" && c.CompareWithScore != "all" {
+			c.CompareWithScore = "pass"
+			return fmt.Sprintf("%s=%s is not one of pass, pass_or_warn, all - using pass", key, value), false
+		}
+	case "kqg.compare.results":
+		results, err := strconv.Atoi(value)
+		if err != nil || results < 1 {
+			c.CompareResults = 1
+			return fmt.Sprintf("%s=%s is not a positive number - using 1", key, value), false
+		}
+		c.CompareResults = results
+	case "kqg.compare.function":
+		c.CompareFunction = strings.ToLower(value)
+		if c.CompareFunction != "avg" && c.CompareFunction != "p50" && c.CompareFunction != "p90" && c.CompareFunction != "p95" {
+			c.CompareFunction = "avg"
+			return fmt.Sprintf("%s=%s is not one of avg, p50, p90, p95 - using avg", key, value), false
+		}
+	case "kqg.querybehavior":
+		switch strings.ToLower(value) {
+		case strings.ToLower(QueryBehaviorParseOnChange):
+			c.QueryBehavior = QueryBehaviorParseOnChange
+		case strings.ToLower(QueryBehaviorAlways), "overwrite":
+			c.QueryBehavior = QueryBehaviorAlways
+		default:
+			return fmt.Sprintf("%s=%s is not one of %s, %s", key, value, QueryBehaviorAlways, QueryBehaviorParseOnChange), false
+		}
+	case "kqg.default.pass", "kqg.default.warning":
+		criteria := strings.Split(value, ",")
+		for _, criterion := range criteria {
+			if !criteriaExpression.MatchString(strings.TrimSpace(criterion)) {
+				return fmt.Sprintf("%s contains the invalid criteria '%s', e.g: <=500 or <+10%%", key, criterion), false
+			}
+		}
+		if strings.ToLower(key) == "kqg.default.pass" {
+			c.DefaultPass = criteria
+		} else {
+			c.DefaultWarning = criteria
+		}
+	case "kqg.default.weight":
+		weight, err := strconv.Atoi(value)
+		if err != nil || weight < 1 {
+			return fmt.Sprintf("%s=%s is not a positive number", key, value), false
+		}
+		c.DefaultWeight = weight
+	case "kqg.default.key":
+		keySli, err := strconv.ParseBool(value)
+		if err != nil {
+			return fmt.Sprintf("%s=%s is not true or false", key, value), false
+		}
+		c.DefaultKey = &keySli
+	case "kqg.exclude":
+		for _, excluded := range strings.Split(value, ",") {
+			if excluded = strings.TrimSpace(excluded); excluded != "" {
+				c.Exclude = append(c.Exclude, excluded)
+			}
+		}
+	case "kqg.indicatorname":
+		for _, placeholder := range indicatorNamePlaceholderExpression.FindAllString(value, -1) {
+			if placeholder != IndicatorNameTemplateSLI && placeholder != IndicatorNameTemplateTile {
+				return fmt.Sprintf("%s=%s uses the unknown placeholder %s - use %s or %s", key, value, placeholder, IndicatorNameTemplateSLI, IndicatorNameTemplateTile), false
+			}
+		}
+		// without a placeholder all tiles would end up with the same indicator name
+		if !strings.Contains(value, IndicatorNameTemplateSLI) && !strings.Contains(value, IndicatorNameTemplateTile) {
+			return fmt.Sprintf("%s=%s must contain %s or %s", key, value, IndicatorNameTemplateSLI, IndicatorNameTemplateTile), false
+		}
+		c.IndicatorName = value
+	default:
+		return fmt.Sprintf("%s is not a known setting", key), true
+	}
+
+	return "", false
+}
+
+// ApplyToSLO sets the total score & comparison settings that were specified in the markdown on the SLO
+func (c *MarkdownConfiguration) ApplyToSLO(slo *keptncommon.ServiceLevelObjectives) {
+	if c.TotalPass != "" {
+		slo.TotalScore.Pass = c.TotalPass
+	}
+	if c.TotalWarning != "" {
+		slo.TotalScore.Warning = c.TotalWarning
+	}
+	if c.CompareWithScore != "" {
+		slo.Comparison.IncludeResultWithScore = c.CompareWithScore
+	}
+	if c.CompareResults > 0 {
+		slo.Comparison.NumberOfComparisonResults = c.CompareResults
+		if c.CompareResults > 1 {
+			slo.Comparison.CompareWith = "several_results"
+		} else {
+			slo.Comparison.CompareWith = "single_result"
+		}
+	}
+	if c.CompareFunction != "" {
+		slo.Comparison.AggregateFunction = c.CompareFunction
+	}
+}
+
+// IsExcluded returns true if the tile title (without its name-value pairs) or the sli name of the tile is listed in KQG.Exclude
+func (c *MarkdownConfiguration) IsExcluded(tileTitle string) bool {
+	if len(c.Exclude) == 0 {
+		return false
+	}
+
+	title := strings.TrimSpace(strings.Split(tileTitle, ";")[0])
+	sliName, _, _, _, _ := ParsePassAndWarningFromString(tileTitle, []string{}, []string{})
+	for _, excluded := range c.Exclude {
+		if strings.EqualFold(excluded, title) || (sliName != "" && strings.EqualFold(excluded, sliName)) {
+			return true
+		}
+	}
+
+	return false
+}
+
+/**
+ * ApplyTileDefaults applies the dashboard wide defaults & the indicator name template to the title of a tile
+ * Defaults only fill in what the tile (or its section) doesnt specify. Tiles without sli= are not changed
+ * Example: Response time;sli=rt with KQG.Default.Weight=2;KQG.IndicatorName=perf_$SLI becomes Response time;sli=perf_rt;weight=2
+ */
+func (c *MarkdownConfiguration) ApplyTileDefaults(tileTitle string) string {
+	defaults := ""
+	if len(c.DefaultPass) > 0 {
+		defaults += ";pass=" + strings.Join(c.DefaultPass, ",")
+	}
+	if len(c.DefaultWarning) > 0 {
+		defaults += ";warning=" + strings.Join(c.DefaultWarning, ",")
+	}
+	if c.DefaultWeight > 0 {
+		defaults += fmt.Sprintf(";weight=%d", c.DefaultWeight)
+	}
+	if c.DefaultKey != nil {
+		defaults += fmt.Sprintf(";key=%t", *c.DefaultKey)
+	}
+	tileTitle = ApplySectionDefaults(tileTitle, defaults)
+
+	if c.IndicatorName == "" {
+		return tileTitle
+	}
+
+	nameValueSplits := strings.Split(tileTitle, ";")
+	title := CleanIndicatorName(strings.TrimSpace(nameValueSplits[0]))
+	for i, nameValue := range nameValueSplits {
+		if strings.HasPrefix(strings.ToLower(nameValue), "sli=") {
+			indicatorName := strings.ReplaceAll(c.IndicatorName, IndicatorNameTemplateSLI, nameValue[len("sli="):])
+			indicatorName = strings.ReplaceAll(indicatorName, IndicatorNameTemplateTile, title)
+			nameValueSplits[i] = nameValue[:len("sli=")] + indicatorName
+		}
+	}
+
+	return strings.Join(nameValueSplits, ";")
+}
@@ -0,0 +1,167 @@
+package common
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestMarkdownConfigurationParse(t *testing.T) {
+	keySli := true
+	tests := []struct {
+		name       string
+		markdown   string
+		want       MarkdownConfiguration
+		wantErrors []MarkdownConfigurationError
+	}{
+		{
+			name:     "text without settings",
+			markdown: "## Service Performance (SLI/SLO)\nSee the readme for KQG.Total.Pass=90%",
+			want:     MarkdownConfiguration{},
+		},
+		{
+			name:     "settings separated by semicolon and newline",
+			markdown: "## Quality Gate\nKQG.Total.Pass=90%; KQG.Total.Warning = 75%\nkqg.compare.results=3;KQG.QueryBehavior=parseonchange",
+			want:     MarkdownConfiguration{TotalPass: "90%", TotalWarning: "75%", CompareResults: 3, QueryBehavior: QueryBehaviorParseOnChange},
+		},
+		{
+			name:     "tile defaults, exclusions and indicator name",
+			markdown: "KQG.Default.Pass=<+10%,<500;KQG.Default.Warning=<+20%;KQG.Default.Weight=2;KQG.Default.Key=true;KQG.Exclude=Debug chart, tmp_rt;KQG.Exclude=other;KQG.IndicatorName=perf_$SLI",
+			want: MarkdownConfiguration{
+				DefaultPass:    []string{"<+10%", "<500"},
+				DefaultWarning: []string{"<+20%"},
+				DefaultWeight:  2,
+				DefaultKey:     &keySli,
+				Exclude:        []string{"Debug chart", "tmp_rt", "other"},
+				IndicatorName:  "perf_$SLI",
+			},
+		},
+		{
+			name:     "unknown key and missing value",
+			markdown: "KQG.Total.Pass=90%\n  KQG.Totl.Warning=75%;KQG.Compare.Results",
+			want:     MarkdownConfiguration{TotalPass: "90%"},
+			wantErrors: []MarkdownConfigurationError{
+				{Line: 2, Column: 3, Message: "KQG.Totl.Warning is not a known setting"},
+				{Line: 2, Column: 24, Message: "KQG.Compare.Results is missing '=' and a value"},
+			},
+		},
+		{
+			name:     "invalid values",
+			markdown: "KQG.Total.Pass=ninety;KQG.Compare.Function=p99;KQG.Default.Pass=500;KQG.IndicatorName=$SERVICE_$SLI;KQG.Default.Weight=",
+			want:     MarkdownConfiguration{CompareFunction: "avg"},
+			wantErrors: []MarkdownConfigurationError{
+				{Line: 1, Column: 16, Message: "KQG.Total.Pass=ninety is not a percentage, e.g: 90%"},
+				{Line: 1, Column: 44, Message: "KQG.Compare.Function=p99 is not one of avg, p50, p90, p95 - using avg"},
+				{Line: 1, Column: 65, Message: "KQG.Default.Pass contains the invalid criteria '500', e.g: <=500 or <+10%"},
+				{Line: 1, Column: 87, Message: "KQG.IndicatorName=$SERVICE_$SLI uses the unknown placeholder $SERVICE - use $SLI or $TILE"},
+				{Line: 1, Column: 120, Message: "KQG.Default.Weight has no value"},
+			},
+		},
+		{
+			name:     "indicator name without placeholder",
+			markdown: "KQG.IndicatorName=rt",
+			want:     MarkdownConfiguration{},
+			wantErrors: []MarkdownConfigurationError{
+				{Line: 1, Column: 19, Message: "KQG.IndicatorName=rt must contain $SLI or $TILE"},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := MarkdownConfiguration{}
+			errors := got.Parse(tt.markdown)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Parse() got = %+v, want %+v", got, tt.want)
+			}
+
+			var gotErrors []MarkdownConfigurationError
+			for _, err := range errors {
+				gotErrors = append(gotErrors, *err)
+			}
+			if !reflect.DeepEqual(gotErrors, tt.wantErrors) {
+				t.Errorf("Parse() errors = %+v, want %+v", gotErrors, tt.wantErrors)
+			}
+		})
+	}
+}
+
+func TestMarkdownConfigurationApplyTileDefaults(t *testing.T) {
+	keySli := false
+	markdownConfig := MarkdownConfiguration{
+		DefaultPass:   []string{"<+10%"},
+		DefaultWeight: 2,
+		DefaultKey:    &keySli,
+		IndicatorName: "perf_$SLI_$TILE",
+	}
+
+	tests := []struct {
+		name      string
+		tileTitle string
+		want      string
+	}{
+		{
+			name:      "defaults are added",
+			tileTitle: "Response time;sli=rt",
+			want:      "Response time;sli=perf_rt_Response_time;pass=<+10%;weight=2;key=false",
+		},
+		{
+			name:      "tile values win",
+			tileTitle: "Response time;sli=rt;pass=<500;weight=1",
+			want:      "Response time;sli=perf_rt_Response_time;pass=<500;weight=1;key=false",
+		},
+		{
+			name:      "tiles without sli are not changed",
+			tileTitle: "Response time",
+			want:      "Response time",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := markdownConfig.ApplyTileDefaults(tt.tileTitle); got != tt.want {
+				t.Errorf("ApplyTileDefaults() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMarkdownConfigurationIsExcluded(t *testing.T) {
+	markdownConfig := MarkdownConfiguration{Exclude: []string{"Debug chart", "tmp_rt"}}
+
+	tests := []struct {
+		tileTitle string
+		want      bool
+	}{
+		{tileTitle: "Debug chart;sli=debug", want: true},
+		{tileTitle: "debug CHART", want: true},
+		{tileTitle: "Response time;sli=tmp_rt;pass=<500", want: true},
+		{tileTitle: "Response time;sli=rt", want: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.tileTitle, func(t *testing.T) {
+			if got := markdownConfig.IsExcluded(tt.tileTitle); got != tt.want {
+				t.Errorf("IsExcluded() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMarkdownConfigurationParseTags(t *testing.T) {
+	got := MarkdownConfiguration{}
+	errors := got.ParseTags([]string{"keptn_project:sockshop", "kqg.total.pass:80%", "KQG.Compare.Results: 3", "kqg.exclude:Debug chart", "kqg.total.warning", "kqg.compare.function:p99"})
+
+	want := MarkdownConfiguration{TotalPass: "80%", CompareResults: 3, Exclude: []string{"Debug chart"}, CompareFunction: "avg"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("ParseTags() got = %+v, want %+v", got, want)
+	}
+
+	wantErrors := []string{
+		"tag 'kqg.total.warning' is missing ':' and a value",
+		"tag 'kqg.compare.function:p99': kqg.compare.function=p99 is not one of avg, p50, p90, p95 - using avg",
+	}
+	var gotErrors []string
+	for _, err := range errors {
+		gotErrors = append(gotErrors, err.Error())
+	}
+	if !reflect.DeepEqual(gotErrors, wantErrors) {
+		t.Errorf("ParseTags() errors = %v, want %v", gotErrors, wantErrors)
+	}
+}
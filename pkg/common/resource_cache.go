@@ -0,0 +1,172 @@
+package common
+
+import (
+	"os"
+	"sync"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// DefaultResourceCacheTTL is used if RESOURCE_CACHE_TTL is not set. Caching across evaluations is opt-in as a cached resource is served without checking whether it was changed in the meantime
+const DefaultResourceCacheTTL = 0 * time.Second
+
+// cachedResource is a single entry in the ResourceCache
+// done is closed as soon as the resource has been loaded - this allows concurrent callers to wait for a load that is already in flight
+type cachedResource struct {
+	branch   string
+	content  string
+	commitID string
+	err      error
+	loadedAt time.Time
+	done     chan struct{}
+}
+
+// ResourceCache caches Keptn configuration resources keyed on project/stage/service/resourceURI
+// Entries are invalidated once their TTL expired, once InvalidateBranch is called or once a resource on the same branch is loaded with a different commit ID.
+// A cached resource is served without checking its commit ID - the commit ID is only seen when a resource is loaded. Changes pushed by users are therefore in general only picked up once the TTL expired - call InvalidateBranch before reads that must see the latest version
+// Only resources that were loaded or dont exist are cached - other errors, e.g: timeouts, are returned to all callers waiting for that load but the next call loads the resource again
+type ResourceCache struct {
+	ttl       time.Duration
+	mutex     sync.Mutex
+	resources map[string]*cachedResource
+}
+
+// NewResourceCache creates a new ResourceCache. A ttl <= 0 disables caching
+func NewResourceCache(ttl time.Duration) *ResourceCache {
+	return &ResourceCache{
+		ttl:       ttl,
+		resources: map[string]*cachedResource{},
+	}
+}
+
+var resourceCache = NewResourceCache(getResourceCacheTTL())
+
+/**
+ * Reads the cache TTL from RESOURCE_CACHE_TTL, e.g: 30s, 5m. 0 disables the cache
+ */
+func getResourceCacheTTL() time.Duration {
+	ttlString := os.Getenv("RESOURCE_CACHE_TTL")
+	if ttlString == "" {
+		return DefaultResourceCacheTTL
+	}
+
+	ttl, err := time.ParseDuration(ttlString)
+	if err != nil {
+		log.WithError(err).WithField("RESOURCE_CACHE_TTL", ttlString).Error("Invalid resource cache TTL, using default")
+		return DefaultResourceCacheTTL
+	}
+	return ttl
+}
+
+// resourceCacheKey returns the key of a resource on a specific config level
+func resourceCacheKey(keptnEvent *BaseKeptnEvent, resourceURI string, level string) string {
+	switch level {
+	case ConfigLevelProject:
+		return keptnEvent.Project + "///" + resourceURI
+	case ConfigLevelStage:
+		return keptnEvent.Project + "/" + keptnEvent.Stage + "//" + resourceURI
+	default:
+		return keptnEvent.Project + "/" + keptnEvent.Stage + "/" + keptnEvent.Service + "/" + resourceURI
+	}
+}
+
+// resourceCacheBranch returns the git branch a resource on that level is stored on. Project level resources are on the default branch, everything else on the stage branch
+func resourceCacheBranch(keptnEvent *BaseKeptnEvent, level string) string {
+	if level == ConfigLevelProject {
+		return keptnEvent.Project
+	}
+	return keptnEvent.Project + "/" + keptnEvent.Stage
+}
+
+// Load returns the cached content and commit ID for key or calls loader to load them
+// Concurrent calls for the same key will only call loader once
+func (c *ResourceCache) Load(key string, branch string, loader func() (string, string, error)) (string, string, error) {
+	if c.ttl <= 0 {
+		return loader()
+	}
+
+	c.mutex.Lock()
+	entry, found := c.resources[key]
+	if found && time.Since(entry.loadedAt) < c.ttl {
+		c.mutex.Unlock()
+		<-entry.done
+		return entry.content, entry.commitID, entry.err
+	}
+
+	c.evictExpired()
+	entry = &cachedResource{
+		branch:   branch,
+		loadedAt: time.Now(),
+		done:     make(chan struct{}),
+	}
+	c.resources[key] = entry
+	c.mutex.Unlock()
+
+	entry.content, entry.commitID, entry.err = loader()
+	close(entry.done)
+
+	// a failed load, e.g: a timeout of the configuration-service, is not cached. A resource that doesnt exist is
+	if entry.err != nil && entry.err != ErrResourceNotFound {
+		c.mutex.Lock()
+		if c.resources[key] == entry {
+			delete(c.resources, key)
+		}
+		c.mutex.Unlock()
+		return entry.content, entry.commitID, entry.err
+	}
+
+	// a different commit ID means the branch has changed - so - all other resources we have cached for that branch are outdated
+	if entry.commitID != "" {
+		c.mutex.Lock()
+		for otherKey, otherEntry := range c.resources {
+			if otherEntry.branch != branch || otherEntry == entry {
+				continue
+			}
+			select {
+			case <-otherEntry.done:
+				if otherEntry.commitID != entry.commitID {
+					delete(c.resources, otherKey)
+				}
+			default:
+			}
+		}
+		c.mutex.Unlock()
+	}
+
+	return entry.content, entry.commitID, entry.err
+}
+
+// evictExpired removes all loaded entries whose TTL expired so that the cache doesnt keep resources that are never read again. The mutex has to be locked
+func (c *ResourceCache) evictExpired() {
+	for key, entry := range c.resources {
+		if time.Since(entry.loadedAt) < c.ttl {
+			continue
+		}
+		select {
+		case <-entry.done:
+			delete(c.resources, key)
+		default:
+		}
+	}
+}
+
+// InvalidateBranch removes all cached resources of a branch, e.g: after we uploaded a resource to it
+func (c *ResourceCache) InvalidateBranch(branch string) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	for key, entry := range c.resources {
+		if entry.branch == branch {
+			delete(c.resources, key)
+		}
+	}
+}
+
+// Clear removes all cached resources
+func (c *ResourceCache) Clear() {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	c.resources = map[string]*cachedResource{}
+}
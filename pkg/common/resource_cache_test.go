@@ -0,0 +1,179 @@
+package common
+
+import (
+	"errors"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestResourceCacheLoadsOnlyOnce(t *testing.T) {
+	cache := NewResourceCache(time.Minute)
+
+	var mutex sync.Mutex
+	loaderCalls := 0
+	loader := func() (string, string, error) {
+		mutex.Lock()
+		loaderCalls++
+		mutex.Unlock()
+		time.Sleep(10 * time.Millisecond)
+		return "content", "commit1", nil
+	}
+
+	var wg sync.WaitGroup
+	for i := 0; i < 5; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			content, _, err := cache.Load("project/stage/service/dynatrace/sli.yaml", "project/stage", loader)
+			if err != nil || content != "content" {
+				t.Errorf("Load() returned %s, %v", content, err)
+			}
+		}()
+	}
+	wg.Wait()
+
+	if loaderCalls != 1 {
+		t.Errorf("expected loader to be called once but was called %d times", loaderCalls)
+	}
+}
+
+func TestResourceCacheCachesOnlySuccessAndNotFound(t *testing.T) {
+	cache := NewResourceCache(time.Minute)
+
+	loaderCalls := 0
+	loader := func() (string, string, error) {
+		loaderCalls++
+		return "", "", errors.New("timeout")
+	}
+
+	cache.Load("key", "branch", loader)
+	_, _, err := cache.Load("key", "branch", loader)
+
+	if err == nil {
+		t.Errorf("expected error to be returned")
+	}
+	if loaderCalls != 2 {
+		t.Errorf("expected a failed load to be retried but loader was called %d times", loaderCalls)
+	}
+
+	notFoundCalls := 0
+	notFoundLoader := func() (string, string, error) {
+		notFoundCalls++
+		return "", "", ErrResourceNotFound
+	}
+
+	cache.Load("missing", "branch", notFoundLoader)
+	_, _, err = cache.Load("missing", "branch", notFoundLoader)
+
+	if err != ErrResourceNotFound {
+		t.Errorf("expected cached ErrResourceNotFound but got %v", err)
+	}
+	if notFoundCalls != 1 {
+		t.Errorf("expected a missing resource to be cached but loader was called %d times", notFoundCalls)
+	}
+}
+
+func TestResourceCacheTTL(t *testing.T) {
+	cache := NewResourceCache(time.Millisecond)
+
+	loaderCalls := 0
+	loader := func() (string, string, error) {
+		loaderCalls++
+		return "content", "", nil
+	}
+
+	cache.Load("key", "branch", loader)
+	time.Sleep(5 * time.Millisecond)
+	cache.Load("key", "branch", loader)
+
+	if loaderCalls != 2 {
+		t.Errorf("expected expired entry to be reloaded but loader was called %d times", loaderCalls)
+	}
+}
+
+// Caching across evaluations is opt-in - without RESOURCE_CACHE_TTL every read loads the resource
+func TestResourceCacheIsDisabledByDefault(t *testing.T) {
+	t.Setenv("RESOURCE_CACHE_TTL", "")
+	cache := NewResourceCache(getResourceCacheTTL())
+
+	loaderCalls := 0
+	loader := func() (string, string, error) {
+		loaderCalls++
+		return "content", "commit1", nil
+	}
+
+	cache.Load("key", "branch", loader)
+	cache.Load("key", "branch", loader)
+
+	if loaderCalls != 2 {
+		t.Errorf("expected every read to load the resource but loader was called %d times", loaderCalls)
+	}
+
+	t.Setenv("RESOURCE_CACHE_TTL", "1m")
+	if ttl := getResourceCacheTTL(); ttl != time.Minute {
+		t.Errorf("expected RESOURCE_CACHE_TTL=1m to enable caching but got ttl %v", ttl)
+	}
+}
+
+func TestResourceCacheEvictsExpiredEntries(t *testing.T) {
+	cache := NewResourceCache(time.Millisecond)
+
+	cache.Load("a", "project/stage", func() (string, string, error) { return "a", "", nil })
+	time.Sleep(5 * time.Millisecond)
+	cache.Load("b", "project/stage", func() (string, string, error) { return "b", "", nil })
+
+	if _, found := cache.resources["a"]; found {
+		t.Errorf("expired entry should have been evicted")
+	}
+	if _, found := cache.resources["b"]; !found {
+		t.Errorf("new entry should be cached")
+	}
+}
+
+func TestResourceCacheInvalidatesOnNewCommitID(t *testing.T) {
+	cache := NewResourceCache(time.Minute)
+
+	sliLoads := 0
+	cache.Load("sli", "project/stage", func() (string, string, error) {
+		sliLoads++
+		return "sli", "commit1", nil
+	})
+	cache.Load("other", "project", func() (string, string, error) {
+		return "other", "commit1", nil
+	})
+
+	// a resource on the same branch with a newer commit invalidates the sli entry
+	cache.Load("slo", "project/stage", func() (string, string, error) {
+		return "slo", "commit2", nil
+	})
+
+	cache.Load("sli", "project/stage", func() (string, string, error) {
+		sliLoads++
+		return "sli", "commit2", nil
+	})
+
+	if sliLoads != 2 {
+		t.Errorf("expected sli to be reloaded after commit changed but was loaded %d times", sliLoads)
+	}
+
+	if _, found := cache.resources["other"]; !found {
+		t.Errorf("entry on a different branch should not have been invalidated")
+	}
+}
+
+func TestResourceCacheInvalidateBranch(t *testing.T) {
+	cache := NewResourceCache(time.Minute)
+
+	cache.Load("a", "project/stage", func() (string, string, error) { return "a", "", nil })
+	cache.Load("b", "project", func() (string, string, error) { return "b", "", nil })
+
+	cache.InvalidateBranch("project/stage")
+
+	if _, found := cache.resources["a"]; found {
+		t.Errorf("entry should have been invalidated")
+	}
+	if _, found := cache.resources["b"]; !found {
+		t.Errorf("entry on a different branch should not have been invalidated")
+	}
+}
@@ -0,0 +1,189 @@
+package common
+
+import (
+	"fmt"
+
+	"gopkg.in/yaml.v2"
+)
+
+// SLIDashboardIndicatorsKey lists the indicators of a merged sli.yaml that were generated from dashboards
+const SLIDashboardIndicatorsKey = "dashboard_indicators"
+
+// SLODashboardObjectivesKey lists the objectives of a merged slo.yaml that were generated from dashboards
+const SLODashboardObjectivesKey = "dashboard_objectives"
+
+/**
+ * Merges a generated sli.yaml into the existing one (writeMode: merge)
+ * Indicators & fields of the user are kept. Generated indicators are added or updated and listed in dashboard_indicators.
+ * Indicators that were generated before but are no longer generated are removed. The dashboard_version is always taken from the generated sli.yaml
+ */
+func MergeGeneratedSLI(existingContent string, generatedContent []byte) ([]byte, error) {
+	existing, generated, err := parseResourcesForMerge(existingContent, generatedContent)
+	if err != nil {
+		return nil, fmt.Errorf("Couldnt merge %s: %v", DynatraceSLIFilename, err)
+	}
+
+	previouslyGenerated := getMapSliceStrings(existing, SLIDashboardIndicatorsKey)
+	existingIndicators, _ := getMapSliceValue(existing, "indicators").(yaml.MapSlice)
+	generatedIndicators, _ := getMapSliceValue(generated, "indicators").(yaml.MapSlice)
+
+	var indicators yaml.MapSlice
+	var generatedNames []string
+	for _, indicator := range existingIndicators {
+		name := fmt.Sprint(indicator.Key)
+		if generatedIndicator := getMapSliceValue(generatedIndicators, name); generatedIndicator != nil {
+			indicators = append(indicators, yaml.MapItem{Key: indicator.Key, Value: generatedIndicator})
+			generatedNames = append(generatedNames, name)
+		} else if !containsString(previouslyGenerated, name) {
+			indicators = append(indicators, indicator)
+		}
+	}
+	for _, indicator := range generatedIndicators {
+		name := fmt.Sprint(indicator.Key)
+		if !containsString(generatedNames, name) {
+			indicators = append(indicators, indicator)
+			generatedNames = append(generatedNames, name)
+		}
+	}
+
+	merged := mergeMapSliceFields(existing, generated, "dashboard_version")
+	merged = setMapSliceValue(merged, "indicators", indicators)
+	merged = setMapSliceValue(merged, SLIDashboardIndicatorsKey, generatedNames)
+
+	return yaml.Marshal(merged)
+}
+
+/**
+ * Merges a generated slo.yaml into the existing one (writeMode: merge)
+ * Objectives & fields of the user, e.g: comparison or total_score, are kept. Generated objectives are added or updated and listed in dashboard_objectives - fields of an objective that werent generated, e.g: displayName, are kept.
+ * Objectives that were generated before but are no longer generated are removed
+ */
+func MergeGeneratedSLO(existingContent string, generatedContent []byte) ([]byte, error) {
+	existing, generated, err := parseResourcesForMerge(existingContent, generatedContent)
+	if err != nil {
+		return nil, fmt.Errorf("Couldnt merge %s: %v", KeptnSLOFilename, err)
+	}
+
+	previouslyGenerated := getMapSliceStrings(existing, SLODashboardObjectivesKey)
+	existingObjectives, _ := getMapSliceValue(existing, "objectives").([]interface{})
+	generatedObjectives, _ := getMapSliceValue(generated, "objectives").([]interface{})
+
+	generatedBySLI := map[string]yaml.MapSlice{}
+	for _, generatedObjective := range generatedObjectives {
+		if objective, isMap := generatedObjective.(yaml.MapSlice); isMap {
+			generatedBySLI[fmt.Sprint(getMapSliceValue(objective, "sli"))] = objective
+		}
+	}
+
+	var objectives []interface{}
+	var generatedNames []string
+	for _, existingObjective := range existingObjectives {
+		objective, isMap := existingObjective.(yaml.MapSlice)
+		if !isMap {
+			objectives = append(objectives, existingObjective)
+			continue
+		}
+
+		name := fmt.Sprint(getMapSliceValue(objective, "sli"))
+		if generatedObjective, found := generatedBySLI[name]; found {
+			for _, field := range generatedObjective {
+				objective = setMapSliceValue(objective, fmt.Sprint(field.Key), field.Value)
+			}
+			objectives = append(objectives, objective)
+			generatedNames = append(generatedNames, name)
+		} else if !containsString(previouslyGenerated, name) {
+			objectives = append(objectives, objective)
+		}
+	}
+	for _, generatedObjective := range generatedObjectives {
+		objective, isMap := generatedObjective.(yaml.MapSlice)
+		if !isMap {
+			continue
+		}
+		name := fmt.Sprint(getMapSliceValue(objective, "sli"))
+		if !containsString(generatedNames, name) {
+			objectives = append(objectives, objective)
+			generatedNames = append(generatedNames, name)
+		}
+	}
+
+	merged := mergeMapSliceFields(existing, generated)
+	merged = setMapSliceValue(merged, "objectives", objectives)
+	merged = setMapSliceValue(merged, SLODashboardObjectivesKey, generatedNames)
+
+	return yaml.Marshal(merged)
+}
+
+/**
+ * Parses the existing & the generated resource. An empty existing resource is valid - in that case everything is generated
+ */
+func parseResourcesForMerge(existingContent string, generatedContent []byte) (yaml.MapSlice, yaml.MapSlice, error) {
+	existing := yaml.MapSlice{}
+	err := yaml.Unmarshal([]byte(existingContent), &existing)
+	if err != nil {
+		return nil, nil, fmt.Errorf("existing file is not valid yaml: %v", err)
+	}
+
+	generated := yaml.MapSlice{}
+	err = yaml.Unmarshal(generatedContent, &generated)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return existing, generated, nil
+}
+
+/**
+ * Returns the existing fields and adds the generated fields that dont exist yet. The generated value of the passed generatedFields always wins
+ */
+func mergeMapSliceFields(existing yaml.MapSlice, generated yaml.MapSlice, generatedFields ...string) yaml.MapSlice {
+	merged := append(yaml.MapSlice{}, existing...)
+	for _, field := range generated {
+		key := fmt.Sprint(field.Key)
+		if getMapSliceValue(merged, key) == nil || containsString(generatedFields, key) {
+			merged = setMapSliceValue(merged, key, field.Value)
+		}
+	}
+	return merged
+}
+
+func getMapSliceValue(mapSlice yaml.MapSlice, key string) interface{} {
+	for _, item := range mapSlice {
+		if fmt.Sprint(item.Key) == key {
+			return item.Value
+		}
+	}
+	return nil
+}
+
+func getMapSliceStrings(mapSlice yaml.MapSlice, key string) []string {
+	values, _ := getMapSliceValue(mapSlice, key).([]interface{})
+
+	var result []string
+	for _, value := range values {
+		result = append(result, fmt.Sprint(value))
+	}
+	return result
+}
+
+/**
+ * Replaces the value of key or appends it if the key doesnt exist yet
+ */
+func setMapSliceValue(mapSlice yaml.MapSlice, key string, value interface{}) yaml.MapSlice {
+	for ix, item := range mapSlice {
+		if fmt.Sprint(item.Key) == key {
+			mapSlice[ix].Value = value
+			return mapSlice
+		}
+	}
+	return append(mapSlice, yaml.MapItem{Key: key, Value: value})
+}
+
+func containsString(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
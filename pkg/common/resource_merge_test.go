@@ -0,0 +1,175 @@
+package common
+
+import (
+	"testing"
+)
+
+func TestMergeGeneratedSLI(t *testing.T) {
+	generated := []byte(`spec_version: 0.1.4
+dashboard_version: dashboard;versions=3;sha256=new
+indicators:
+  rt: MV2;MicroSecond;metricSelector=builtin:service.response.time:merge(0):avg:names
+  tp: MV2;Count;metricSelector=builtin:service.requestCount.total:merge(0):value:names
+`)
+
+	tests := []struct {
+		name     string
+		existing string
+		want     string
+	}{
+		{
+			name:     "no existing sli.yaml",
+			existing: "",
+			want: `spec_version: 0.1.4
+dashboard_version: dashboard;versions=3;sha256=new
+indicators:
+  rt: MV2;MicroSecond;metricSelector=builtin:service.response.time:merge(0):avg:names
+  tp: MV2;Count;metricSelector=builtin:service.requestCount.total:merge(0):value:names
+dashboard_indicators:
+- rt
+- tp
+`,
+		},
+		{
+			name: "user indicators are kept and indicators the dashboard no longer generates are removed",
+			existing: `spec_version: '1.0'
+dashboard_version: dashboard;versions=3;sha256=old
+indicators:
+  custom: USQL;SINGLE_VALUE;;SELECT count(*) FROM usersession
+  rt: MV2;MicroSecond;metricSelector=builtin:service.response.time:percentile(95):names
+  error_rate: MV2;Percent;metricSelector=builtin:service.errors.total.rate:merge(0):avg:names
+dashboard_indicators:
+- rt
+- error_rate
+`,
+			want: `spec_version: "1.0"
+dashboard_version: dashboard;versions=3;sha256=new
+indicators:
+  custom: USQL;SINGLE_VALUE;;SELECT count(*) FROM usersession
+  rt: MV2;MicroSecond;metricSelector=builtin:service.response.time:merge(0):avg:names
+  tp: MV2;Count;metricSelector=builtin:service.requestCount.total:merge(0):value:names
+dashboard_indicators:
+- rt
+- tp
+`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := MergeGeneratedSLI(tt.existing, generated)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(got) != tt.want {
+				t.Errorf("MergeGeneratedSLI() = \n%s\nwant\n%s", got, tt.want)
+			}
+		})
+	}
+
+	if _, err := MergeGeneratedSLI("indicators: [", generated); err == nil {
+		t.Errorf("Expected an error for an invalid existing sli.yaml")
+	}
+}
+
+func TestMergeGeneratedSLO(t *testing.T) {
+	generated := []byte(`spec_version: "1.0"
+filter: {}
+comparison:
+  compare_with: single_result
+  include_result_with_score: pass
+  number_of_comparison_results: 1
+  aggregate_function: avg
+objectives:
+- sli: rt
+  pass:
+  - criteria:
+    - <+10%
+  weight: 1
+- sli: tp
+  weight: 1
+total_score:
+  pass: 90%
+  warning: 75%
+`)
+
+	existing := `spec_version: "1.0"
+comparison:
+  compare_with: several_results
+  number_of_comparison_results: 3
+objectives:
+- sli: custom
+  displayName: Custom sessions
+  pass:
+  - criteria:
+    - '>100'
+- sli: rt
+  displayName: Response time
+  pass:
+  - criteria:
+    - <+20%
+  weight: 2
+- sli: error_rate
+  weight: 1
+total_score:
+  pass: 80%
+dashboard_objectives:
+- rt
+- error_rate
+`
+
+	want := `spec_version: "1.0"
+comparison:
+  compare_with: several_results
+  number_of_comparison_results: 3
+objectives:
+- sli: custom
+  displayName: Custom sessions
+  pass:
+  - criteria:
+    - '>100'
+- sli: rt
+  displayName: Response time
+  pass:
+  - criteria:
+    - <+10%
+  weight: 1
+- sli: tp
+  weight: 1
+total_score:
+  pass: 80%
+dashboard_objectives:
+- rt
+- tp
+filter: {}
+`
+
+	got, err := MergeGeneratedSLO(existing, generated)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(got) != want {
+		t.Errorf("MergeGeneratedSLO() = \n%s\nwant\n%s", got, want)
+	}
+}
+
+func TestGetWriteMode(t *testing.T) {
+	tests := []struct {
+		writeMode string
+		want      string
+	}{
+		{writeMode: "", want: DynatraceConfigWriteModeOVERWRITE},
+		{writeMode: "Merge", want: DynatraceConfigWriteModeMERGE},
+		{writeMode: "never", want: DynatraceConfigWriteModeNEVER},
+		{writeMode: "OVERWRITE", want: DynatraceConfigWriteModeOVERWRITE},
+		{writeMode: "sometimes", want: DynatraceConfigWriteModeNEVER},
+		{writeMode: "nevr", want: DynatraceConfigWriteModeNEVER},
+		{writeMode: "Merge ", want: DynatraceConfigWriteModeNEVER},
+	}
+	for _, tt := range tests {
+		t.Run(tt.writeMode, func(t *testing.T) {
+			if got := (DynatraceConfigFile{WriteMode: tt.writeMode}).GetWriteMode(); got != tt.want {
+				t.Errorf("GetWriteMode() = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,183 @@
+package common
+
+import (
+	"errors"
+	"fmt"
+	"os"
+
+	keptnmodels "github.com/keptn/go-utils/pkg/api/models"
+	keptnapi "github.com/keptn/go-utils/pkg/api/utils"
+	log "github.com/sirupsen/logrus"
+)
+
+/**
+ * Constants for selecting the ResourceStore through the RESOURCE_STORE env variable
+ */
+const ResourceStoreConfigurationService = "configuration-service"
+const ResourceStoreLocal = "local"
+const ResourceStoreGit = "git"
+
+// ErrResourceNotFound is returned by a ResourceStore if the requested resource doesnt exist
+var ErrResourceNotFound = errors.New("resource not found")
+
+// ResourceStore reads and writes resources of a Keptn configuration repository
+type ResourceStore interface {
+	// GetResource returns the content and the commit ID of a resource on the given config level (Project, Stage, Service)
+	GetResource(keptnEvent *BaseKeptnEvent, resourceURI string, level string) (string, string, error)
+
+	// UploadResource stores a resource on service level
+	UploadResource(keptnEvent *BaseKeptnEvent, resourceURI string, content []byte) error
+}
+
+var resourceStore = newResourceStoreFromEnv()
+
+// SetResourceStore replaces the ResourceStore used by GetKeptnResource, GetKeptnResourceOnConfigLevel & UploadKeptnResource and returns the previous one
+func SetResourceStore(store ResourceStore) ResourceStore {
+	previousStore := resourceStore
+	resourceStore = store
+	return previousStore
+}
+
+// InvalidateCachedKeptnResources drops the cached resources of the event's stage so that the next read returns the latest version, e.g: before merging into a resource
+func InvalidateCachedKeptnResources(keptnEvent *BaseKeptnEvent) {
+	if cachingStore, isCaching := resourceStore.(*CachingResourceStore); isCaching {
+		cachingStore.cache.InvalidateBranch(resourceCacheBranch(keptnEvent, ConfigLevelService))
+	}
+}
+
+/**
+ * Creates the ResourceStore based on RESOURCE_STORE (configuration-service, local, git) and RESOURCE_STORE_DIR
+ * In RunLocal mode we always read and write the current directory
+ */
+func newResourceStoreFromEnv() ResourceStore {
+	if RunLocal || RunLocalTest {
+		return NewLocalResourceStore(".")
+	}
+
+	directory := os.Getenv("RESOURCE_STORE_DIR")
+	switch os.Getenv("RESOURCE_STORE") {
+	case ResourceStoreLocal:
+		return NewLocalResourceStore(directory)
+	case ResourceStoreGit:
+		return NewGitResourceStore(directory, os.Getenv("RESOURCE_STORE_GIT_DEFAULT_BRANCH"))
+	case "", ResourceStoreConfigurationService:
+		return NewCachingResourceStore(newConfigurationServiceResourceStoreFromEnv(), resourceCache)
+	default:
+		log.WithField("RESOURCE_STORE", os.Getenv("RESOURCE_STORE")).Error("Unknown resource store, using configuration-service")
+		return NewCachingResourceStore(newConfigurationServiceResourceStoreFromEnv(), resourceCache)
+	}
+}
+
+/**
+ * On a remote execution plane (KEPTN_API_URL is set) the configuration-service is accessed through the Keptn API gateway
+ * otherwise we talk to the configuration-service directly
+ */
+func newConfigurationServiceResourceStoreFromEnv() *ConfigurationServiceResourceStore {
+	if keptnAPIConfig := GetKeptnAPIConfig(); keptnAPIConfig != nil {
+		return NewKeptnAPIResourceStore(keptnAPIConfig)
+	}
+	return NewConfigurationServiceResourceStore(GetConfigurationServiceURL())
+}
+
+// ConfigurationServiceResourceStore reads and writes resources through the Keptn configuration-service
+type ConfigurationServiceResourceStore struct {
+	resourceHandler *keptnapi.ResourceHandler
+}
+
+// NewConfigurationServiceResourceStore creates a ResourceStore for the configuration-service at configurationServiceURL
+func NewConfigurationServiceResourceStore(configurationServiceURL string) *ConfigurationServiceResourceStore {
+	return &ConfigurationServiceResourceStore{
+		resourceHandler: keptnapi.NewResourceHandler(configurationServiceURL),
+	}
+}
+
+// NewKeptnAPIResourceStore creates a ResourceStore that accesses the configuration-service through the Keptn API gateway
+func NewKeptnAPIResourceStore(keptnAPIConfig *KeptnAPIConfig) *ConfigurationServiceResourceStore {
+	resourceHandler := keptnapi.NewAuthenticatedResourceHandler(keptnAPIConfig.BaseURL, keptnAPIConfig.Token, KeptnAPITokenHeader, nil, keptnAPIConfig.Scheme)
+
+	// the resource handler always skips TLS verification - so - we replace its transport with one that honors our settings
+	resourceHandler.HTTPClient.Transport = keptnAPIConfig.NewTransport()
+
+	return &ConfigurationServiceResourceStore{
+		resourceHandler: resourceHandler,
+	}
+}
+
+// GetResource returns the content and commit ID of a resource on the given config level
+func (s *ConfigurationServiceResourceStore) GetResource(keptnEvent *BaseKeptnEvent, resourceURI string, level string) (string, string, error) {
+	var keptnResourceContent *keptnmodels.Resource
+	var err error
+	switch level {
+	case ConfigLevelProject:
+		keptnResourceContent, err = s.resourceHandler.GetProjectResource(keptnEvent.Project, resourceURI)
+	case ConfigLevelStage:
+		keptnResourceContent, err = s.resourceHandler.GetStageResource(keptnEvent.Project, keptnEvent.Stage, resourceURI)
+	case ConfigLevelService:
+		keptnResourceContent, err = s.resourceHandler.GetServiceResource(keptnEvent.Project, keptnEvent.Stage, keptnEvent.Service, resourceURI)
+	default:
+		return "", "", errors.New("Config level not valid: " + level)
+	}
+
+	if err == keptnapi.ResourceNotFoundError {
+		return "", "", ErrResourceNotFound
+	}
+	if err != nil {
+		return "", "", err
+	}
+
+	if keptnResourceContent == nil {
+		return "", "", errors.New("Found resource " + resourceURI + " on level " + level + " but didnt contain content")
+	}
+
+	commitID := ""
+	if keptnResourceContent.Metadata != nil {
+		commitID = keptnResourceContent.Metadata.Version
+	}
+
+	return keptnResourceContent.ResourceContent, commitID, nil
+}
+
+// UploadResource uploads a resource on service level
+func (s *ConfigurationServiceResourceStore) UploadResource(keptnEvent *BaseKeptnEvent, resourceURI string, content []byte) error {
+	resources := []*keptnmodels.Resource{{ResourceContent: string(content), ResourceURI: &resourceURI}}
+	_, err := s.resourceHandler.CreateResources(keptnEvent.Project, keptnEvent.Stage, keptnEvent.Service, resources)
+	if err != nil {
+		return fmt.Errorf("Couldnt upload remote resource %s: %s", resourceURI, *err.Message)
+	}
+
+	return nil
+}
+
+// CachingResourceStore serves resources of another ResourceStore through a ResourceCache
+type CachingResourceStore struct {
+	store ResourceStore
+	cache *ResourceCache
+}
+
+// NewCachingResourceStore wraps store with cache
+func NewCachingResourceStore(store ResourceStore, cache *ResourceCache) *CachingResourceStore {
+	return &CachingResourceStore{
+		store: store,
+		cache: cache,
+	}
+}
+
+// GetResource returns the cached resource or loads it from the underlying store
+func (s *CachingResourceStore) GetResource(keptnEvent *BaseKeptnEvent, resourceURI string, level string) (string, string, error) {
+	key := resourceCacheKey(keptnEvent, resourceURI, level)
+	branch := resourceCacheBranch(keptnEvent, level)
+
+	return s.cache.Load(key, branch, func() (string, string, error) {
+		return s.store.GetResource(keptnEvent, resourceURI, level)
+	})
+}
+
+// UploadResource uploads the resource through the underlying store and invalidates the cached resources of the stage
+func (s *CachingResourceStore) UploadResource(keptnEvent *BaseKeptnEvent, resourceURI string, content []byte) error {
+	err := s.store.UploadResource(keptnEvent, resourceURI, content)
+
+	// the upload creates a new commit on the stage branch - so - everything we have cached for it is outdated
+	s.cache.InvalidateBranch(resourceCacheBranch(keptnEvent, ConfigLevelService))
+
+	return err
+}
@@ -0,0 +1,180 @@
+package common
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path"
+	"strings"
+	"sync"
+)
+
+// DefaultGitBranch is the branch that holds project level resources in a Keptn configuration repository
+const DefaultGitBranch = "master"
+
+// GitResourceStore reads and writes resources in a local git repository that follows Keptn's branch-per-stage layout
+//
+//	project level: <resourceURI> on the default branch
+//	stage level:   <resourceURI> on the <stage> branch
+//	service level: <service>/<resourceURI> on the <stage> branch
+//
+// Resources are read from and committed to the branches directly - the working tree is never touched
+type GitResourceStore struct {
+	directory     string
+	defaultBranch string
+	mutex         sync.Mutex
+}
+
+// NewGitResourceStore creates a ResourceStore for the git repository in directory
+func NewGitResourceStore(directory string, defaultBranch string) *GitResourceStore {
+	if directory == "" {
+		directory = "."
+	}
+	if defaultBranch == "" {
+		defaultBranch = DefaultGitBranch
+	}
+	return &GitResourceStore{
+		directory:     directory,
+		defaultBranch: defaultBranch,
+	}
+}
+
+// getBranchAndPath returns the branch and the path within that branch of a resource on the given config level
+func (s *GitResourceStore) getBranchAndPath(keptnEvent *BaseKeptnEvent, resourceURI string, level string) (string, string, error) {
+	resourceURI = strings.TrimPrefix(resourceURI, "/")
+	switch level {
+	case ConfigLevelProject:
+		return s.defaultBranch, resourceURI, nil
+	case ConfigLevelStage:
+		return keptnEvent.Stage, resourceURI, nil
+	case ConfigLevelService:
+		return keptnEvent.Stage, path.Join(keptnEvent.Service, resourceURI), nil
+	default:
+		return "", "", fmt.Errorf("Config level not valid: %s", level)
+	}
+}
+
+/**
+ * Executes a git command in the repository directory and returns the trimmed stdout
+ */
+func (s *GitResourceStore) git(env []string, stdin []byte, args ...string) (string, error) {
+	cmd := exec.Command("git", append([]string{"-C", s.directory}, args...)...)
+	cmd.Env = append(os.Environ(), env...)
+	if stdin != nil {
+		cmd.Stdin = bytes.NewReader(stdin)
+	}
+
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		return "", fmt.Errorf("git %s failed: %v %s", args[0], err, strings.TrimSpace(stderr.String()))
+	}
+
+	return strings.TrimSpace(stdout.String()), nil
+}
+
+/**
+ * Returns the commit ID of the head of branch or "" if the branch doesnt exist
+ */
+func (s *GitResourceStore) getBranchCommitID(branch string) string {
+	commitID, err := s.git(nil, nil, "rev-parse", "--verify", "-q", "refs/heads/"+branch)
+	if err != nil {
+		return ""
+	}
+	return commitID
+}
+
+// GetResource reads a resource from its branch. The commit ID is the head of that branch
+func (s *GitResourceStore) GetResource(keptnEvent *BaseKeptnEvent, resourceURI string, level string) (string, string, error) {
+	branch, resourcePath, err := s.getBranchAndPath(keptnEvent, resourceURI, level)
+	if err != nil {
+		return "", "", err
+	}
+
+	commitID := s.getBranchCommitID(branch)
+	if commitID == "" {
+		return "", "", ErrResourceNotFound
+	}
+
+	// we use cat-file as it doesnt apply any text conversion on the content
+	if _, err := s.git(nil, nil, "cat-file", "-e", commitID+":"+resourcePath); err != nil {
+		return "", "", ErrResourceNotFound
+	}
+
+	cmd := exec.Command("git", "-C", s.directory, "cat-file", "blob", commitID+":"+resourcePath)
+	content, err := cmd.Output()
+	if err != nil {
+		return "", "", fmt.Errorf("git cat-file failed for %s on branch %s: %v", resourcePath, branch, err)
+	}
+
+	return string(content), commitID, nil
+}
+
+// UploadResource commits a resource on service level to the stage branch
+func (s *GitResourceStore) UploadResource(keptnEvent *BaseKeptnEvent, resourceURI string, content []byte) error {
+	branch, resourcePath, _ := s.getBranchAndPath(keptnEvent, resourceURI, ConfigLevelService)
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	blobID, err := s.git(nil, content, "hash-object", "-w", "--stdin")
+	if err != nil {
+		return err
+	}
+
+	// we build the new tree in a temporary index so neither the index nor the working tree of the repository is changed
+	indexFile, err := ioutil.TempFile("", "dynatrace-sli-service-index")
+	if err != nil {
+		return fmt.Errorf("Couldnt create temporary git index: %v", err)
+	}
+	indexFile.Close()
+	os.Remove(indexFile.Name())
+	defer os.Remove(indexFile.Name())
+
+	env := []string{
+		"GIT_INDEX_FILE=" + indexFile.Name(),
+		"GIT_AUTHOR_NAME=dynatrace-sli-service",
+		"GIT_AUTHOR_EMAIL=dynatrace-sli-service@keptn.sh",
+		"GIT_COMMITTER_NAME=dynatrace-sli-service",
+		"GIT_COMMITTER_EMAIL=dynatrace-sli-service@keptn.sh",
+	}
+
+	parentCommitID := s.getBranchCommitID(branch)
+	if parentCommitID != "" {
+		if _, err := s.git(env, nil, "read-tree", parentCommitID); err != nil {
+			return err
+		}
+	}
+
+	if _, err := s.git(env, nil, "update-index", "--add", "--cacheinfo", "100644,"+blobID+","+resourcePath); err != nil {
+		return err
+	}
+
+	treeID, err := s.git(env, nil, "write-tree")
+	if err != nil {
+		return err
+	}
+
+	commitArgs := []string{"commit-tree", treeID, "-m", "Uploaded resource " + resourcePath}
+	if parentCommitID != "" {
+		commitArgs = append(commitArgs, "-p", parentCommitID)
+	}
+	commitID, err := s.git(env, nil, commitArgs...)
+	if err != nil {
+		return err
+	}
+
+	// passing the old commit ID makes sure we dont overwrite a commit that was created in the meantime
+	updateRefArgs := []string{"update-ref", "refs/heads/" + branch, commitID}
+	if parentCommitID != "" {
+		updateRefArgs = append(updateRefArgs, parentCommitID)
+	}
+	if _, err := s.git(env, nil, updateRefArgs...); err != nil {
+		return fmt.Errorf("Couldnt commit resource %s to branch %s: %v", resourcePath, branch, err)
+	}
+
+	return nil
+}
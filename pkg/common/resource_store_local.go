@@ -0,0 +1,78 @@
+package common
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// LocalResourceStore reads and writes resources in a local directory tree with the following layout
+//
+//	project level: <directory>/<resourceURI>
+//	stage level:   <directory>/<stage>/<resourceURI>
+//	service level: <directory>/<stage>/<service>/<resourceURI>
+type LocalResourceStore struct {
+	directory string
+}
+
+// NewLocalResourceStore creates a ResourceStore for the passed directory
+func NewLocalResourceStore(directory string) *LocalResourceStore {
+	if directory == "" {
+		directory = "."
+	}
+	return &LocalResourceStore{directory: directory}
+}
+
+// getResourcePath returns the local file path of a resource on the given config level
+func (s *LocalResourceStore) getResourcePath(keptnEvent *BaseKeptnEvent, resourceURI string, level string) (string, error) {
+	switch level {
+	case ConfigLevelProject:
+		return filepath.Join(s.directory, resourceURI), nil
+	case ConfigLevelStage:
+		return filepath.Join(s.directory, keptnEvent.Stage, resourceURI), nil
+	case ConfigLevelService:
+		return filepath.Join(s.directory, keptnEvent.Stage, keptnEvent.Service, resourceURI), nil
+	default:
+		return "", fmt.Errorf("Config level not valid: %s", level)
+	}
+}
+
+// GetResource reads a resource from the local directory tree. There is no commit ID for local files
+func (s *LocalResourceStore) GetResource(keptnEvent *BaseKeptnEvent, resourceURI string, level string) (string, string, error) {
+	resourcePath, err := s.getResourcePath(keptnEvent, resourceURI, level)
+	if err != nil {
+		return "", "", err
+	}
+
+	localFileContent, err := ioutil.ReadFile(resourcePath)
+	if os.IsNotExist(err) {
+		return "", "", ErrResourceNotFound
+	}
+	if err != nil {
+		return "", "", err
+	}
+
+	log.WithField("resourcePath", resourcePath).Info("Loaded LOCAL file")
+	return string(localFileContent), "", nil
+}
+
+// UploadResource writes a resource on service level into the local directory tree
+func (s *LocalResourceStore) UploadResource(keptnEvent *BaseKeptnEvent, resourceURI string, content []byte) error {
+	resourcePath, _ := s.getResourcePath(keptnEvent, resourceURI, ConfigLevelService)
+
+	err := os.MkdirAll(filepath.Dir(resourcePath), 0755)
+	if err != nil {
+		return fmt.Errorf("Couldnt create local directory for %s: %v", resourcePath, err)
+	}
+
+	err = ioutil.WriteFile(resourcePath, content, 0644)
+	if err != nil {
+		return fmt.Errorf("Couldnt write local file %s: %v", resourcePath, err)
+	}
+
+	log.WithField("resourcePath", resourcePath).Info("Local file written")
+	return nil
+}
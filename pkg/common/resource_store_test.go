@@ -0,0 +1,110 @@
+package common
+
+import (
+	"os/exec"
+	"testing"
+	"time"
+)
+
+func testingGetResourceStoreKeptnEvent() *BaseKeptnEvent {
+	return &BaseKeptnEvent{
+		Project: "sockshop",
+		Stage:   "dev",
+		Service: "carts",
+	}
+}
+
+// testResourceStore validates the level lookup & upload behavior that all ResourceStores have in common
+func testResourceStore(t *testing.T, store ResourceStore) {
+	keptnEvent := testingGetResourceStoreKeptnEvent()
+
+	_, _, err := store.GetResource(keptnEvent, DynatraceSLIFilename, ConfigLevelService)
+	if err != ErrResourceNotFound {
+		t.Errorf("GetResource() on empty store returned %v, expected ErrResourceNotFound", err)
+	}
+
+	err = store.UploadResource(keptnEvent, DynatraceSLIFilename, []byte("indicators:\n  throughput: builtin:service.requestCount.total\n"))
+	if err != nil {
+		t.Fatalf("UploadResource() returned %v", err)
+	}
+
+	content, _, err := store.GetResource(keptnEvent, DynatraceSLIFilename, ConfigLevelService)
+	if err != nil {
+		t.Errorf("GetResource() returned %v", err)
+	}
+	if content != "indicators:\n  throughput: builtin:service.requestCount.total\n" {
+		t.Errorf("GetResource() returned unexpected content %s", content)
+	}
+
+	// a service level resource must not be visible on stage or project level
+	if _, _, err = store.GetResource(keptnEvent, DynatraceSLIFilename, ConfigLevelStage); err != ErrResourceNotFound {
+		t.Errorf("GetResource() on stage level returned %v, expected ErrResourceNotFound", err)
+	}
+	if _, _, err = store.GetResource(keptnEvent, DynatraceSLIFilename, ConfigLevelProject); err != ErrResourceNotFound {
+		t.Errorf("GetResource() on project level returned %v, expected ErrResourceNotFound", err)
+	}
+}
+
+func TestLocalResourceStore(t *testing.T) {
+	testResourceStore(t, NewLocalResourceStore(t.TempDir()))
+}
+
+func TestGitResourceStore(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not available")
+	}
+
+	directory := t.TempDir()
+	if output, err := exec.Command("git", "init", "-q", directory).CombinedOutput(); err != nil {
+		t.Fatalf("git init failed: %v %s", err, output)
+	}
+
+	store := NewGitResourceStore(directory, "")
+	testResourceStore(t, store)
+
+	keptnEvent := testingGetResourceStoreKeptnEvent()
+
+	// the upload has to be committed to the stage branch within the service folder
+	output, err := exec.Command("git", "-C", directory, "show", "dev:carts/dynatrace/sli.yaml").CombinedOutput()
+	if err != nil {
+		t.Errorf("uploaded resource not committed to stage branch: %v %s", err, output)
+	}
+
+	// a second upload must create a new commit on top of the first one
+	_, firstCommitID, _ := store.GetResource(keptnEvent, DynatraceSLIFilename, ConfigLevelService)
+	if err := store.UploadResource(keptnEvent, KeptnSLOFilename, []byte("objectives: []\n")); err != nil {
+		t.Fatalf("UploadResource() returned %v", err)
+	}
+	content, secondCommitID, err := store.GetResource(keptnEvent, DynatraceSLIFilename, ConfigLevelService)
+	if err != nil || content == "" {
+		t.Errorf("previously uploaded resource got lost: %v", err)
+	}
+	if firstCommitID == "" || firstCommitID == secondCommitID {
+		t.Errorf("expected a new commit ID after upload, got %s and %s", firstCommitID, secondCommitID)
+	}
+}
+
+func TestInvalidateCachedKeptnResources(t *testing.T) {
+	localStore := NewLocalResourceStore(t.TempDir())
+	previousStore := SetResourceStore(NewCachingResourceStore(localStore, NewResourceCache(time.Minute)))
+	t.Cleanup(func() { SetResourceStore(previousStore) })
+
+	keptnEvent := testingGetResourceStoreKeptnEvent()
+	if _, err := GetKeptnResourceOnConfigLevel(keptnEvent, KeptnSLOFilename, ConfigLevelService); err != ErrResourceNotFound {
+		t.Fatalf("GetKeptnResourceOnConfigLevel() returned %v, expected ErrResourceNotFound", err)
+	}
+
+	// a user pushes the resource - the cache doesnt know about it
+	if err := localStore.UploadResource(keptnEvent, KeptnSLOFilename, []byte("objectives: []\n")); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := GetKeptnResourceOnConfigLevel(keptnEvent, KeptnSLOFilename, ConfigLevelService); err != ErrResourceNotFound {
+		t.Errorf("expected the missing resource to be served from the cache but got %v", err)
+	}
+
+	InvalidateCachedKeptnResources(keptnEvent)
+	content, err := GetKeptnResourceOnConfigLevel(keptnEvent, KeptnSLOFilename, ConfigLevelService)
+	if err != nil || content != "objectives: []\n" {
+		t.Errorf("expected the pushed resource after invalidating the cache but got %s, %v", content, err)
+	}
+}
@@ -1,6 +1,8 @@
 package dynatrace
 
 import (
+	"bytes"
+	"crypto/sha256"
 	"crypto/tls"
 	"encoding/json"
 	"errors"
@@ -14,6 +16,7 @@ import (
 	"time"
 
 	log "github.com/sirupsen/logrus"
+	"gopkg.in/yaml.v2"
 
 	keptnv2 "github.com/keptn/go-utils/pkg/lib/v0_2_0"
 
@@ -52,13 +55,16 @@ type DTUSQLResult struct {
 
 // SLI struct for SLI.yaml
 type SLI struct {
-	SpecVersion string            `yaml:"spec_version"`
-	Indicators  map[string]string `yaml:"indicators"`
+	SpecVersion string `yaml:"spec_version"`
+	// DashboardVersion is the version of the dashboard the SLIs were generated from - see getDashboardVersion
+	DashboardVersion string            `yaml:"dashboard_version,omitempty"`
+	Indicators       map[string]string `yaml:"indicators"`
 }
 
+// Filter of a DATA_EXPLORER query. Criteria apply to the Filter dimension, criteria and nested filters are combined with FilterOperator (AND, OR)
 type NestedFilterDataExplorer struct {
-	Filter         string                     `json:"filter"`
-	FilterType     string                     `json:"filterType"`
+	Filter         string                     `json:"filter,omitempty"`
+	FilterType     string                     `json:"filterType,omitempty"`
 	FilterOperator string                     `json:"filterOperator"`
 	NestedFilters  []NestedFilterDataExplorer `json:"nestedFilters"`
 	Criteria       []struct {
@@ -68,20 +74,38 @@ type NestedFilterDataExplorer struct {
 }
 
 // Query Definition for DATA_EXPLORER dashboard tile
+// Queries in code mode only have a MetricSelector, e.g: builtin:service.errors.total.count:splitBy():sum or an expression of other queries, e.g: (A / B) * 100
 type DataExplorerQuery struct {
-	ID               string   `json:"id"`
-	Metric           string   `json:"metric"`
-	SpaceAggregation string   `json:"spaceAggregation"`
-	TimeAggregation  string   `json:"timeAggregation"`
-	SplitBy          []string `json:"splitBy"`
-	FilterBy         *struct {
-		FilterOperator string                     `json:"filterOperator"`
-		NestedFilters  []NestedFilterDataExplorer `json:"nestedFilters"`
-		Criteria       []struct {
-			Value     string `json:"value"`
-			Evaluator string `json:"evaluator"`
-		} `json:"criteria"`
-	} `json:"filterBy,omitempty"`
+	ID               string                    `json:"id"`
+	Metric           string                    `json:"metric"`
+	SpaceAggregation string                    `json:"spaceAggregation"`
+	TimeAggregation  string                    `json:"timeAggregation"`
+	SplitBy          []string                  `json:"splitBy"`
+	FilterBy         *NestedFilterDataExplorer `json:"filterBy,omitempty"`
+	MetricSelector   string                    `json:"metricSelector,omitempty"`
+	Enabled          *bool                     `json:"enabled,omitempty"`
+}
+
+// Threshold of a data explorer tile - each rule colors the values starting at its value
+type VisualThreshold struct {
+	AxisTarget string `json:"axisTarget,omitempty"`
+	QueryID    string `json:"queryId"`
+	Visible    bool   `json:"visible"`
+	Rules      []struct {
+		Value *float64 `json:"value,omitempty"`
+		Color string   `json:"color"`
+	} `json:"rules"`
+}
+
+// Visual configuration of a data explorer tile, e.g: GRAPH_CHART or SINGLE_VALUE, and its thresholds
+type VisualConfig struct {
+	Type   string `json:"type"`
+	Global *struct {
+		Theme      string            `json:"theme,omitempty"`
+		Thresholds []VisualThreshold `json:"thresholds,omitempty"`
+		SeriesType string            `json:"seriesType,omitempty"`
+	} `json:"global,omitempty"`
+	Thresholds []VisualThreshold `json:"thresholds,omitempty"`
 }
 
 // Chart Series for a regular Chart
@@ -141,8 +165,8 @@ type DynatraceDashboard struct {
 		Configured bool   `json:"configured"`
 		Query      string `json:"query"`
 		Type       string `json:"type"`
-		CustomName string `json:"customName`
-		Markdown   string `json:"markdown`
+		CustomName string `json:"customName"`
+		Markdown   string `json:"markdown"`
 		Bounds     struct {
 			Top    int `json:"top"`
 			Left   int `json:"left"`
@@ -156,8 +180,12 @@ type DynatraceDashboard struct {
 				Name string `json:"name"`
 			} `json:"managementZone,omitempty"`
 		} `json:"tileFilter"`
-		Queries          []DataExplorerQuery `json:"queries"`
-		AssignedEntities []string            `json:"assignedEntities"`
+		Queries         []DataExplorerQuery `json:"queries"`
+		QueriesSettings *struct {
+			Resolution string `json:"resolution"`
+		} `json:"queriesSettings,omitempty"`
+		VisualConfig     *VisualConfig `json:"visualConfig,omitempty"`
+		AssignedEntities []string      `json:"assignedEntities"`
 		FilterConfig     struct {
 			Type        string `json:"type"`
 			CustomName  string `json:"customName"`
@@ -400,6 +428,17 @@ type DynatraceProblemQueryResult struct {
 	Problems   []DynatraceProblem `json:"problems"`
 }
 
+// Result of /api/v2/entities
+type DynatraceEntitiesQueryResult struct {
+	TotalCount  int    `json:"totalCount"`
+	PageSize    int    `json:"pageSize"`
+	NextPageKey string `json:"nextPageKey"`
+	Entities    []struct {
+		EntityID    string `json:"entityId"`
+		DisplayName string `json:"displayName"`
+	} `json:"entities"`
+}
+
 // Result of/api/v2/securityProblems
 type DynatraceSecurityProblemQueryResult struct {
 	TotalCount       int                        `json:"totalCount"`
@@ -408,6 +447,78 @@ type DynatraceSecurityProblemQueryResult struct {
 	SecurityProblems []DynatraceSecurityProblem `json:"securityProblems"`
 }
 
+/**
+ * Decisions of a TileParseReport
+ */
+const TileDecisionIncluded = "included"
+const TileDecisionSkipped = "skipped"
+const TileDecisionFailed = "failed"
+
+// DashboardParseReport lists how every tile of a dashboard was parsed
+type DashboardParseReport struct {
+	DashboardID   string             `json:"dashboardId"`
+	DashboardName string             `json:"dashboardName"`
+	Tiles         []*TileParseReport `json:"tiles"`
+}
+
+// TileParseReport describes whether a tile became SLIs - and if not - why not
+type TileParseReport struct {
+	Index      int      `json:"index"`
+	TileType   string   `json:"tileType"`
+	Title      string   `json:"title"`
+	Decision   string   `json:"decision"`
+	Reason     string   `json:"reason,omitempty"`
+	Queries    []string `json:"queries,omitempty"`
+	Indicators []string `json:"indicators,omitempty"`
+}
+
+/**
+ * Marks the tile as failed and adds the reason unless it is already listed
+ */
+func (r *TileParseReport) addFailure(reason string) {
+	r.Decision = TileDecisionFailed
+	for _, existingReason := range strings.Split(r.Reason, "; ") {
+		if existingReason == reason {
+			return
+		}
+	}
+	if r.Reason != "" {
+		r.Reason = r.Reason + "; "
+	}
+	r.Reason = r.Reason + reason
+}
+
+/**
+ * Adds the SLIResults a tile generated and their queries to the report. The tile is included if all of them succeeded
+ */
+func (r *TileParseReport) addSLIResults(sliResults []*keptnv2.SLIResult, dashboardSLI *SLI) {
+	for _, sliResult := range sliResults {
+		r.Indicators = append(r.Indicators, sliResult.Metric)
+
+		query, found := dashboardSLI.Indicators[sliResult.Metric]
+		for _, existingQuery := range r.Queries {
+			if existingQuery == query {
+				found = false
+			}
+		}
+		if found {
+			r.Queries = append(r.Queries, query)
+		}
+
+		if !sliResult.Success {
+			r.addFailure(sliResult.Message)
+		} else if r.Decision == TileDecisionSkipped {
+			// a tile can be processed by more than one tile processor - so - we drop the reason another one skipped it for
+			r.Decision = TileDecisionIncluded
+			r.Reason = ""
+		}
+	}
+
+	if r.Decision == TileDecisionSkipped && r.Reason == "" {
+		r.Reason = "no SLIs were generated for this tile"
+	}
+}
+
 // Handler interacts with a dynatrace API endpoint
 type Handler struct {
 	ApiURL        string
@@ -418,6 +529,22 @@ type Handler struct {
 	Headers       map[string]string
 	CustomQueries map[string]string
 	CustomFilters []*keptnv2.SLIFilter
+
+	// EntitySelector is the entitySelector template (or preset) used by the built-in SLIs
+	EntitySelector string
+
+	// DashboardOwner & DashboardSharedOnly filter the dashboards considered when selecting a dashboard by name
+	DashboardOwner      string
+	DashboardSharedOnly bool
+
+	// DashboardProblems collects the problems found while parsing dashboards, e.g: invalid KQG settings in a markdown, so they can be reported back to the dashboard author
+	DashboardProblems []string
+
+	// DashboardParseReports describe for every parsed dashboard which tiles became SLIs and why the others didnt
+	DashboardParseReports []*DashboardParseReport
+
+	// DashboardReparsed is true if a dashboard was parsed in this evaluation - false if the SLIs & SLOs generated from an unchanged dashboard were reused
+	DashboardReparsed bool
 }
 
 // NewDynatraceHandler returns a new dynatrace handler that interacts with the Dynatrace REST API
@@ -481,27 +608,144 @@ func IsValidUUID(uuid string) bool {
 }
 
 /**
- * findDynatraceDashboard
- * Queries all Dynatrace Dashboards and returns the dashboard ID that matches the following name patter: KQG;project=%project%;service=%service%;stage=%stage;xxx
- *
- * Returns the UUID of the dashboard that was found. If no dashboard was found it returns ""
+ * Tag keys used to assign a dashboard to a Keptn project, stage and service, e.g: keptn_project:sockshop
  */
-func (ph *Handler) findDynatraceDashboard(keptnEvent *common.BaseKeptnEvent) (string, error) {
-	// Lets query the list of all Dashboards and find the one that matches project, stage, service based on the title (in the future - we can do it via tags)
-	// create dashboard query URL and set additional headers
-	// ph.Logger.Debug(fmt.Sprintf("Query all dashboards\n"))
+const DashboardTagProject = "keptn_project"
+const DashboardTagStage = "keptn_stage"
+const DashboardTagService = "keptn_service"
 
-	dashboardAPIUrl := ph.ApiURL + fmt.Sprintf("/api/config/v1/dashboards")
+/**
+ * getDynatraceDashboards
+ * Queries the list of dashboards. query allows to pass filters supported by the API, e.g: tags, owner
+ */
+func (ph *Handler) getDynatraceDashboards(query url.Values) (*DynatraceDashboards, error) {
+	dashboardAPIUrl := ph.ApiURL + "/api/config/v1/dashboards"
+	if len(query) > 0 {
+		dashboardAPIUrl += "?" + query.Encode()
+	}
 	resp, body, err := ph.executeDynatraceREST("GET", dashboardAPIUrl, nil)
+	if err != nil {
+		return nil, err
+	}
 
 	if resp == nil || resp.StatusCode != 200 {
-		return "", err
+		return nil, fmt.Errorf("No valid response from Dashboard API")
 	}
 
 	// parse json
 	dashboardsJSON := &DynatraceDashboards{}
 	err = json.Unmarshal(body, &dashboardsJSON)
+	if err != nil {
+		return nil, err
+	}
+
+	return dashboardsJSON, nil
+}
+
+/**
+ * getDashboardTagMatchRank
+ * Returns how specific a dashboard with the passed tags matches project, stage and service. The higher the more specific:
+ * 0: no match, e.g: no keptn_project tag or a keptn_stage or keptn_service tag for another stage or service
+ * 1: project-wide - only keptn_project matches
+ * 2: stage-wide - keptn_project and keptn_stage match
+ * 3: service-specific - keptn_project and keptn_service match
+ * 4: service-specific for that stage - keptn_project, keptn_stage and keptn_service match
+ */
+func getDashboardTagMatchRank(tags []string, keptnEvent *common.BaseKeptnEvent) int {
+	// a dashboard can have multiple values for the same tag key, e.g: keptn_stage:staging and keptn_stage:production
+	tagValues := map[string][]string{}
+	for _, tag := range tags {
+		tagSplits := strings.SplitN(tag, ":", 2)
+		if len(tagSplits) != 2 {
+			continue
+		}
+		tagKey := strings.ToLower(strings.TrimSpace(tagSplits[0]))
+		tagValues[tagKey] = append(tagValues[tagKey], strings.ToLower(strings.TrimSpace(tagSplits[1])))
+	}
+
+	// returns -1 if the tag isnt set, 1 if one of its values matches and 0 otherwise
+	matchTag := func(tagKey string, value string) int {
+		values, found := tagValues[tagKey]
+		if !found {
+			return -1
+		}
+		for _, tagValue := range values {
+			if tagValue == strings.ToLower(value) {
+				return 1
+			}
+		}
+		return 0
+	}
+
+	if matchTag(DashboardTagProject, keptnEvent.Project) != 1 {
+		return 0
+	}
+
+	rank := 1
+	switch matchTag(DashboardTagStage, keptnEvent.Stage) {
+	case 0:
+		return 0
+	case 1:
+		rank++
+	}
+	switch matchTag(DashboardTagService, keptnEvent.Service) {
+	case 0:
+		return 0
+	case 1:
+		rank += 2
+	}
+
+	return rank
+}
+
+/**
+ * findDynatraceDashboardByTags
+ * Queries all dashboards tagged with keptn_project:%project% and returns the ID of the one that matches stage and service most specifically
+ * Precedence: service-specific over stage-wide over project-wide. If multiple dashboards have the same precedence the first one is returned
+ *
+ * Returns the UUID of the dashboard that was found. If no dashboard was found it returns ""
+ */
+func (ph *Handler) findDynatraceDashboardByTags(keptnEvent *common.BaseKeptnEvent) (string, error) {
+	dashboardsJSON, err := ph.getDynatraceDashboards(url.Values{"tags": {DashboardTagProject + ":" + keptnEvent.Project}})
+	if err != nil {
+		return "", err
+	}
 
+	// the list of dashboards doesnt contain the tags - so - we have to query each dashboard
+	bestDashboardID := ""
+	bestRank := 0
+	for _, dashboard := range dashboardsJSON.Dashboards {
+		dashboardJSON, err := ph.getDynatraceDashboard(dashboard.ID)
+		if err != nil {
+			log.WithError(err).WithField("dashboard", dashboard.ID).Debug("Couldnt query dashboard tags")
+			continue
+		}
+
+		rank := getDashboardTagMatchRank(dashboardJSON.DashboardMetadata.Tags, keptnEvent)
+		if rank > bestRank {
+			bestDashboardID = dashboard.ID
+			bestRank = rank
+		} else if rank > 0 && rank == bestRank {
+			log.WithFields(
+				log.Fields{
+					"dashboard":        dashboard.ID,
+					"chosenDashboard":  bestDashboardID,
+					"tagMatchingLevel": rank,
+				}).Warn("Multiple dashboards match the keptn tags with the same precedence")
+		}
+	}
+
+	return bestDashboardID, nil
+}
+
+/**
+ * findDynatraceDashboardByName
+ * Queries all Dynatrace Dashboards and returns the dashboard ID that matches the following name patter: KQG;project=%project%;service=%service%;stage=%stage;xxx
+ *
+ * Returns the UUID of the dashboard that was found. If no dashboard was found it returns ""
+ */
+func (ph *Handler) findDynatraceDashboardByName(keptnEvent *common.BaseKeptnEvent) (string, error) {
+	dashboardsJSON, err := ph.getDynatraceDashboards(nil)
 	if err != nil {
 		return "", err
 	}
@@ -538,11 +782,101 @@ func (ph *Handler) findDynatraceDashboard(keptnEvent *common.BaseKeptnEvent) (st
 	return "", nil
 }
 
+/**
+ * findDynatraceDashboard
+ * Finds the dashboard for project, stage & service
+ * -- first by dashboard tags: keptn_project:%project%, keptn_stage:%stage%, keptn_service:%service%
+ * -- then by dashboard name: KQG;project=%project%;service=%service%;stage=%stage;xxx
+ *
+ * Returns the UUID of the dashboard that was found. If no dashboard was found it returns ""
+ */
+func (ph *Handler) findDynatraceDashboard(keptnEvent *common.BaseKeptnEvent) (string, error) {
+	dashboardID, err := ph.findDynatraceDashboardByTags(keptnEvent)
+	if err != nil {
+		log.WithError(err).Debug("Couldnt query dashboards by tags")
+	}
+	if dashboardID != "" {
+		return dashboardID, nil
+	}
+
+	return ph.findDynatraceDashboardByName(keptnEvent)
+}
+
+/**
+ * findDynatraceDashboardByNameTemplate
+ * Resolves dashboard: name:xxx or nameRegex:xxx. Keptn placeholders in the name are replaced first, e.g: name:Quality Gate - $SERVICE ($STAGE)
+ * Only dashboards of DashboardOwner (if set) and only shared dashboards (if DashboardSharedOnly is set) are considered
+ *
+ * Returns the UUID of the dashboard - or an error if none or more than one dashboard matches
+ */
+func (ph *Handler) findDynatraceDashboardByNameTemplate(keptnEvent *common.BaseKeptnEvent, dashboard string) (string, error) {
+	var matchesName func(name string) bool
+	if strings.HasPrefix(dashboard, common.DynatraceConfigDashboardNAMEREGEX) {
+		nameRegex := common.ReplaceKeptnPlaceholdersWithEscaping(strings.TrimPrefix(dashboard, common.DynatraceConfigDashboardNAMEREGEX), keptnEvent, regexp.QuoteMeta)
+		r, err := regexp.Compile(nameRegex)
+		if err != nil {
+			return "", fmt.Errorf("Invalid dashboard name regex %s: %v", nameRegex, err)
+		}
+		matchesName = r.MatchString
+	} else {
+		name := common.ReplaceKeptnPlaceholdersWithEscaping(strings.TrimPrefix(dashboard, common.DynatraceConfigDashboardNAME), keptnEvent, func(value string) string { return value })
+		matchesName = func(dashboardName string) bool {
+			return strings.EqualFold(strings.TrimSpace(dashboardName), strings.TrimSpace(name))
+		}
+	}
+
+	query := url.Values{}
+	if ph.DashboardOwner != "" {
+		query.Set("owner", ph.DashboardOwner)
+	}
+	dashboardsJSON, err := ph.getDynatraceDashboards(query)
+	if err != nil {
+		return "", fmt.Errorf("Couldnt query dashboards: %v", err)
+	}
+
+	var matchingDashboards []string
+	matchingDashboardID := ""
+	for _, dashboardStub := range dashboardsJSON.Dashboards {
+		if !matchesName(dashboardStub.Name) {
+			continue
+		}
+
+		// we check the owner again in case the API ignored the filter
+		if ph.DashboardOwner != "" && dashboardStub.Owner != ph.DashboardOwner {
+			continue
+		}
+
+		// the list of dashboards doesnt tell us whether a dashboard is shared - so - we have to query it
+		if ph.DashboardSharedOnly {
+			dashboardJSON, err := ph.getDynatraceDashboard(dashboardStub.ID)
+			if err != nil {
+				return "", fmt.Errorf("Couldnt query dashboard %s: %v", dashboardStub.ID, err)
+			}
+			if !dashboardJSON.DashboardMetadata.Shared {
+				continue
+			}
+		}
+
+		matchingDashboards = append(matchingDashboards, fmt.Sprintf("%s (%s)", dashboardStub.Name, dashboardStub.ID))
+		matchingDashboardID = dashboardStub.ID
+	}
+
+	switch len(matchingDashboards) {
+	case 0:
+		return "", fmt.Errorf("No dashboard matches %s (owner: '%s', shared only: %t)", dashboard, ph.DashboardOwner, ph.DashboardSharedOnly)
+	case 1:
+		return matchingDashboardID, nil
+	default:
+		return "", fmt.Errorf("%d dashboards match %s: %s", len(matchingDashboards), dashboard, strings.Join(matchingDashboards, ", "))
+	}
+}
+
 /**
  * loadDynatraceDashboard:
  * Depending on the dashboard parameter which is pulled from dynatrace.conf.yaml:dashboard this method either
  * -- query: queries all dashboards on the Dynatrace Tenant and returns the one that matches project/service/stage
  * -- dashboard-ID: if this is a valid dashboard ID it will query the dashboard with this ID, e.g: ddb6a571-4bda-4e8b-a9c0-4a3e02c2e14a
+ * -- name:xxx or nameRegex:xxx: queries the one dashboard whose name matches, e.g: name:Quality Gate - $SERVICE ($STAGE)
  * -- <empty>: will not query any dashboard
 
  * Returns: parsed Dynatrace Dashboard and actual dashboard ID in case we queried a dashboard
@@ -570,6 +904,20 @@ func (ph *Handler) loadDynatraceDashboard(keptnEvent *common.BaseKeptnEvent, das
 		}
 	}
 
+	// Option 1b: find dashboard by name or name regex
+	if strings.HasPrefix(dashboard, common.DynatraceConfigDashboardNAME) || strings.HasPrefix(dashboard, common.DynatraceConfigDashboardNAMEREGEX) {
+		dashboardID, err := ph.findDynatraceDashboardByNameTemplate(keptnEvent, dashboard)
+		if err != nil {
+			return nil, dashboard, err
+		}
+		log.WithFields(
+			log.Fields{
+				"dashboardConfig": dashboard,
+				"dashboard":       dashboardID,
+			}).Debug("Dashboard found by name")
+		dashboard = dashboardID
+	}
+
 	// Option 2: there is no dashboard we should query
 	if dashboard == "" {
 		return nil, dashboard, nil
@@ -583,25 +931,57 @@ func (ph *Handler) loadDynatraceDashboard(keptnEvent *common.BaseKeptnEvent, das
 
 	// We have a valid Dashboard UUID - now lets query it!
 	log.WithField("dashboard", dashboard).Debug("Query dashboard")
-	dashboardAPIUrl := ph.ApiURL + fmt.Sprintf("/api/config/v1/dashboards/%s", dashboard)
+	dashboardJSON, err := ph.getDynatraceDashboard(dashboard)
+	if err != nil {
+		return nil, dashboard, err
+	}
+
+	return dashboardJSON, dashboard, nil
+}
+
+/**
+ * parseDynatraceDashboardFromRepo
+ * Parses the dashboard.json that was loaded from the configuration repo for dashboard: repo
+ * loadErr is the error we got when loading the dashboard.json
+ */
+func parseDynatraceDashboardFromRepo(dashboardContent string, loadErr error) (*DynatraceDashboard, error) {
+	if loadErr != nil || dashboardContent == "" {
+		return nil, fmt.Errorf("Couldnt load %s from the configuration repo: %v", common.DynatraceDashboardFilename, loadErr)
+	}
+
+	dashboardJSON := &DynatraceDashboard{}
+	err := json.Unmarshal([]byte(dashboardContent), &dashboardJSON)
+	if err != nil {
+		return nil, fmt.Errorf("Couldnt parse %s from the configuration repo: %v", common.DynatraceDashboardFilename, err)
+	}
+
+	return dashboardJSON, nil
+}
+
+/**
+ * getDynatraceDashboard
+ * Queries a single dashboard by its ID
+ */
+func (ph *Handler) getDynatraceDashboard(dashboardID string) (*DynatraceDashboard, error) {
+	dashboardAPIUrl := ph.ApiURL + fmt.Sprintf("/api/config/v1/dashboards/%s", dashboardID)
 	resp, body, err := ph.executeDynatraceREST("GET", dashboardAPIUrl, nil)
 
 	if err != nil {
-		return nil, dashboard, err
+		return nil, err
 	}
 
 	if resp == nil || resp.StatusCode != 200 {
-		return nil, dashboard, fmt.Errorf("No valid response from Dashboard API")
+		return nil, fmt.Errorf("No valid response from Dashboard API")
 	}
 
 	// parse json
 	dashboardJSON := &DynatraceDashboard{}
 	err = json.Unmarshal(body, &dashboardJSON)
 	if err != nil {
-		return nil, dashboard, fmt.Errorf("could not decode response payload: %v", err)
+		return nil, fmt.Errorf("could not decode response payload: %v", err)
 	}
 
-	return dashboardJSON, dashboard, nil
+	return dashboardJSON, nil
 }
 
 /**
@@ -862,8 +1242,11 @@ func (ph *Handler) ExecuteUSQLQuery(usql string) (*DTUSQLResult, error) {
 	}
 
 	// parse response json
+	// numbers are kept as json.Number so that large integer columns dont lose precision
 	var result DTUSQLResult
-	err = json.Unmarshal(body, &result)
+	decoder := json.NewDecoder(bytes.NewReader(body))
+	decoder.UseNumber()
+	err = decoder.Decode(&result)
 	if err != nil {
 		return nil, err
 	}
@@ -909,6 +1292,9 @@ func (ph *Handler) BuildDynatraceUSQLQuery(query string, startUnix time.Time, en
 	return u.String()
 }
 
+// queryValueEscaper escapes the characters that change the meaning of a query parameter value, e.g: + becomes a space - everything else is kept so that the SLI definitions stay readable
+var queryValueEscaper = strings.NewReplacer("%", "%25", "&", "%26", "+", "%2B", "#", "%23")
+
 // BuildDynatraceMetricsQuery builds the complete query string based on start, end and filters
 // metricQuery should contain metricSelector and entitySelector
 // Returns:
@@ -970,6 +1356,10 @@ func (ph *Handler) BuildDynatraceMetricsQuery(metricquery string, startUnix time
 	}
 
 	for param, value := range queryParams {
+		// a resolution that is part of the query, e.g: from a data explorer tile, wins
+		if param == "resolution" && q.Get(param) != "" {
+			continue
+		}
 		q.Add(param, value)
 	}
 
@@ -1037,24 +1427,49 @@ func (ph *Handler) isMatchingMetricID(singleResultMetricID string, queryMetricID
 }
 
 /**
- * This function will validate if the current dashboard.json stored in the configuration repo is the same as the one passed as parameter
+ * Returns the version of a dashboard: its ID, its configuration versions and a hash of its content, e.g: 12345678-1111-4444-8888-123456789012;versions=3;sha256=9f86d0...
+ * The cluster version is left out of the hash as updating Dynatrace doesnt change the dashboard
  */
-func (ph *Handler) HasDashboardChanged(keptnEvent *common.BaseKeptnEvent, dashboardJSON *DynatraceDashboard, existingDashboardContent string) bool {
+func getDashboardVersion(dashboardJSON *DynatraceDashboard) string {
+	dashboardContent := *dashboardJSON
+	dashboardContent.Metadata.ClusterVersion = ""
+	jsonAsByteArray, _ := json.Marshal(dashboardContent)
+
+	var configurationVersions []string
+	for _, configurationVersion := range dashboardJSON.Metadata.ConfigurationVersions {
+		configurationVersions = append(configurationVersions, strconv.Itoa(configurationVersion))
+	}
+
+	return fmt.Sprintf("%s;versions=%s;sha256=%x", dashboardJSON.ID, strings.Join(configurationVersions, ","), sha256.Sum256(jsonAsByteArray))
+}
 
-	jsonAsByteArray, _ := json.MarshalIndent(dashboardJSON, "", "  ")
-	newDashboardContent := string(jsonAsByteArray)
+/**
+ * This function will validate if the dashboard changed since the sli.yaml & slo.yaml in the configuration repo were generated from it
+ * They are only reused if the sli.yaml was generated from the same dashboard version - unless the dashboard specifies KQG.QueryBehavior=Always
+ */
+func (ph *Handler) HasDashboardChanged(keptnEvent *common.BaseKeptnEvent, dashboardJSON *DynatraceDashboard) bool {
+	markdownConfig, _ := getDashboardConfiguration(dashboardJSON)
+	if markdownConfig.QueryBehavior == common.QueryBehaviorAlways {
+		return true
+	}
 
-	// If ParseOnChange is not specified we consider this as a dashboard with a change
-	if strings.Index(newDashboardContent, "KQG.QueryBehavior=ParseOnChange") == -1 {
+	existingSLIContent, err := common.GetKeptnResourceOnConfigLevel(keptnEvent, common.DynatraceSLIFilename, common.ConfigLevelService)
+	if err != nil || existingSLIContent == "" {
+		return true
+	}
+	existingSLI := &SLI{}
+	err = yaml.Unmarshal([]byte(existingSLIContent), existingSLI)
+	if err != nil || existingSLI.DashboardVersion != getDashboardVersion(dashboardJSON) {
 		return true
 	}
 
-	// now lets compare the dashboard from the config repo and the one passed to this function
-	if strings.Compare(newDashboardContent, existingDashboardContent) == 0 {
-		return false
+	// without the slo.yaml the sli.yaml cant be evaluated
+	existingSLOContent, err := common.GetKeptnResourceOnConfigLevel(keptnEvent, common.KeptnSLOFilename, common.ConfigLevelService)
+	if err != nil || existingSLOContent == "" {
+		return true
 	}
 
-	return true
+	return false
 }
 
 /**
@@ -1254,219 +1669,1344 @@ func (ph *Handler) ProcessOpenSecurityProblemTile(securityProblemSelector string
 	return sliResult, indicatorName, sliQuery, sloDefinition, nil
 }
 
+// syntheticMonitorMetrics are the availability & duration metrics per type of synthetic monitor
+var syntheticMonitorMetrics = map[string]struct {
+	Availability string
+	Duration     string
+}{
+	"SYNTHETIC_TEST": {Availability: "builtin:synthetic.browser.availability.location.total", Duration: "builtin:synthetic.browser.totalDuration"},
+	"HTTP_CHECK":     {Availability: "builtin:synthetic.http.availability.location.total", Duration: "builtin:synthetic.http.duration.geo"},
+}
+
 /**
- * Looks at the DataExplorerQuery configuration of a data explorer chart and generates the Metrics Query
- * Returns
- * #1: metricId, e.g: built-in:mymetric
- * #2: metricUnit, e.g: MilliSeconds
- * #3: metricQuery, e.g: metricSelector=metric&filter...
- * #4: fullMetricQuery, e.g: metricQuery&from=123213&to=2323
- * #5: entitySelectirSLIDefinition, e.g: ,entityid(FILTERDIMENSIONVALUE)
- * #6: filterSLIDefinitionAttregator, e.g: , filter(eq(Test Step,FILTERDIMENSIONVALUE))
+ * Default SLOs for the SLIs of a synthetic monitor. They can be overwritten through the tile name, e.g: availability.pass=>=99.9;duration.pass=<=2000
  */
-func (ph *Handler) GenerateMetricQueryFromDataExplorer(dataQuery DataExplorerQuery, tileManagementZoneFilter string, startUnix time.Time, endUnix time.Time) (string, string, string, string, string, string, error) {
-
-	// Lets query the metric definition as we need to know how many dimension the metric has
-	metricDefinition, err := ph.ExecuteMetricAPIDescribe(dataQuery.Metric)
-	if err != nil {
-		log.WithError(err).WithField("metric", dataQuery.Metric).Debug("Error retrieving metric description")
-		return "", "", "", "", "", "", err
-	}
-
-	// building the merge aggregator string, e.g: merge(1):merge(0) - or merge(0)
-	metricDimensionCount := len(metricDefinition.DimensionDefinitions)
-	metricAggregation := metricDefinition.DefaultAggregation.Type
-	mergeAggregator := ""
-	filterAggregator := ""
-	filterSLIDefinitionAggregator := ""
-	entitySelectorSLIDefinition := ""
-	entityFilter := ""
-
-	// we need to merge all those dimensions based on the metric definition that are not included in the "splitBy"
-	// so - we iterate through the dimensions based on the metric definition from the back to front - and then merge those not included in splitBy
-	for metricDimIx := metricDimensionCount - 1; metricDimIx >= 0; metricDimIx-- {
-		log.WithField("metricDimIx", metricDimIx).Debug("Processing Dimension Ix")
-
-		doMergeDimension := true
-		for _, splitDimension := range dataQuery.SplitBy {
-			log.WithFields(
-				log.Fields{
-					"dimension1": splitDimension,
-					"dimension2": metricDefinition.DimensionDefinitions[metricDimIx].Key,
-				}).Debug("Comparing Dimensions %")
+const SyntheticAvailabilityDefaultPass = ">=99"
+const SyntheticAvailabilityDefaultWarning = ">=95"
+const SyntheticDurationDefaultPass = "<=+10%"
+const SyntheticDurationDefaultWarning = "<=+25%"
 
-			if strings.Compare(splitDimension, metricDefinition.DimensionDefinitions[metricDimIx].Key) == 0 {
-				doMergeDimension = false
-			}
-		}
+/**
+ * Returns the SLO string of the tile name for one kind of SLI of a tile, e.g: the availability or duration SLI of a synthetic monitor
+ * Values prefixed with the kind, e.g: availability.pass=>=99.9, overwrite the unprefixed values, e.g: weight=2. Values prefixed with another kind are dropped
+ * Unprefixed pass & warning criteria are dropped as well: they dont fit the unit of every kind and are in general section or dashboard defaults meant for single metric tiles, e.g: pass=<=800 for a response time
+ */
+func getTileSLOStringForKind(tileName string, kind string) string {
+	nameValues := strings.Split(tileName, ";")
 
-		if doMergeDimension {
-			// this is a dimension we want to merge as it is not split by in the chart
-			log.WithField("dimension", metricDefinition.DimensionDefinitions[metricDimIx].Key).Debug("merging dimension")
-			mergeAggregator = mergeAggregator + fmt.Sprintf(":merge(%d)", metricDimIx)
+	overwritten := map[string]bool{}
+	for _, nameValue := range nameValues {
+		if strings.HasPrefix(strings.ToLower(nameValue), kind+".") {
+			overwritten[strings.SplitN(strings.ToLower(nameValue)[len(kind)+1:], "=", 2)[0]] = true
 		}
 	}
 
-	// Create the right entity Selectors for the queries execute
-	// TODO: we currently only support a single filter - if we want to support more we need to build this in
-	if dataQuery.FilterBy != nil && len(dataQuery.FilterBy.NestedFilters) > 0 {
-
-		if len(dataQuery.FilterBy.NestedFilters[0].Criteria) == 1 {
-			if strings.HasPrefix(dataQuery.FilterBy.NestedFilters[0].Filter, "dt.entity.") {
-				entitySelectorSLIDefinition = ",entityId(FILTERDIMENSIONVALUE)"
-				entityFilter = fmt.Sprintf("&entitySelector=entityId(%s)", dataQuery.FilterBy.NestedFilters[0].Criteria[0].Value)
-			} else {
-				filterSLIDefinitionAggregator = fmt.Sprintf(":filter(eq(%s,FILTERDIMENSIONVALUE))", dataQuery.FilterBy.NestedFilters[0].Filter)
-				filterAggregator = fmt.Sprintf(":filter(%s(%s,%s))", dataQuery.FilterBy.NestedFilters[0].Criteria[0].Evaluator, dataQuery.FilterBy.NestedFilters[0].Filter, dataQuery.FilterBy.NestedFilters[0].Criteria[0].Value)
-			}
-		} else {
-			log.Debug("Code only supports a single filter for data explorer")
+	var sloString []string
+	for _, nameValue := range nameValues {
+		nameValueDividerIndex := strings.Index(nameValue, "=")
+		if nameValueDividerIndex < 0 {
+			continue
 		}
-	}
-
-	// TODO: we currently only support one split dimension
-	// but - if we split by a dimension we need to include that dimension in our individual SLI query definitions - thats why we hand this back in the filter clause
-	if dataQuery.SplitBy != nil {
-		if len(dataQuery.SplitBy) == 1 {
-			filterSLIDefinitionAggregator = fmt.Sprintf("%s:filter(eq(%s,FILTERDIMENSIONVALUE))", filterSLIDefinitionAggregator, dataQuery.SplitBy[0])
-		} else {
-			log.Debug("Code only supports a single splitby dimension for data explorer")
+		name := strings.ToLower(nameValue[:nameValueDividerIndex])
+		switch {
+		case strings.HasPrefix(name, kind+"."):
+			sloString = append(sloString, name[len(kind)+1:]+nameValue[nameValueDividerIndex:])
+		case strings.Contains(name, "."):
+			// a value for the other kind
+		case name == "pass" || name == "warning":
+			// only criteria for this kind replace its defaults
+		case !overwritten[name]:
+			sloString = append(sloString, nameValue)
 		}
 	}
+	return strings.Join(sloString, ";")
+}
 
-	// lets create the metricSelector and entitySelector
-	// ATTENTION: adding :names so we also get the names of the dimensions and not just the entities. This means we get two values for each dimension
-	metricQuery := fmt.Sprintf("metricSelector=%s%s%s:%s:names%s%s",
-		dataQuery.Metric, mergeAggregator, filterAggregator, strings.ToLower(metricAggregation),
-		entityFilter, tileManagementZoneFilter)
+/**
+ * ExecuteGetDynatraceEntities
+ * Calls the /entities API call to retrieve the entities matching the entitySelector within that timeframe
+ */
+func (ph *Handler) ExecuteGetDynatraceEntities(entitySelector string, startUnix time.Time, endUnix time.Time) (*DynatraceEntitiesQueryResult, error) {
+	targetURL := ph.ApiURL + fmt.Sprintf("/api/v2/entities?from=%s&to=%s&pageSize=500&entitySelector=%s",
+		common.TimestampToString(startUnix),
+		common.TimestampToString(endUnix),
+		url.QueryEscape(entitySelector))
+
+	resp, body, err := ph.executeDynatraceREST("GET", targetURL, nil)
 
-	// lets build the Dynatrace API Metric query for the proposed timeframe and additonal filters!
-	fullMetricQuery, metricID, err := ph.BuildDynatraceMetricsQuery(metricQuery, startUnix, endUnix)
 	if err != nil {
-		return "", "", "", "", "", "", err
+		return nil, err
+	}
+	if resp == nil {
+		return nil, fmt.Errorf("No valid response from entities api for query: %s", targetURL)
 	}
 
-	return metricID, metricDefinition.Unit, metricQuery, fullMetricQuery, entitySelectorSLIDefinition, filterSLIDefinitionAggregator, nil
-}
-
-/**
- * Looks at the ChartSeries configuration of a regular chart and generates the Metrics Query
- * Returns
- * #1: metricId, e.g: built-in:mymetric
- * #2: metricUnit, e.g: MilliSeconds
+	// make sure the status code from the API is 200
+	if resp.StatusCode != 200 {
+		dtApiv2Error := &DtEnvAPIv2Error{}
+		err := json.Unmarshal(body, dtApiv2Error)
+		if err == nil {
+			return nil, fmt.Errorf("Dynatrace API returned status code %d: %s", dtApiv2Error.Error.Code, dtApiv2Error.Error.Message)
+		}
+		return nil, fmt.Errorf("Dynatrace API returned status code %d - Entities could not be received.", resp.StatusCode)
+	}
+
+	// parse response json
+	var result DynatraceEntitiesQueryResult
+	err = json.Unmarshal(body, &result)
+	if err != nil {
+		return nil, err
+	}
+
+	return &result, nil
+}
+
+/**
+ * Processes a Synthetic Tests Tile. Every synthetic monitor assigned to the tile becomes an availability & a duration SLI, e.g: sli=checkout -> checkout_availability_<monitor>, checkout_duration_<monitor>
+ * The default SLOs are availability >= 99% and a duration that is no more than 10% slower than before. The tile name can overwrite them, e.g: sli=checkout;availability.pass=>=99.9;duration.pass=<=2000
+ */
+func (ph *Handler) ProcessSyntheticTestsTile(tileName string, assignedEntities []string, dashboardSLI *SLI, dashboardSLO *keptncommon.ServiceLevelObjectives, startUnix time.Time, endUnix time.Time) []*keptnv2.SLIResult {
+	var sliResults []*keptnv2.SLIResult
+	if len(assignedEntities) == 0 {
+		return sliResults
+	}
+
+	baseIndicatorName, _, _, _, _ := common.ParsePassAndWarningFromString(tileName, []string{}, []string{})
+
+	// resolve the names of the monitors
+	entitySelector := fmt.Sprintf("entityId(%s)", strings.Join(assignedEntities, ","))
+	entities, err := ph.ExecuteGetDynatraceEntities(entitySelector, startUnix, endUnix)
+	if err != nil {
+		log.WithError(err).WithField("entitySelector", entitySelector).Error("Couldnt resolve synthetic monitors")
+		return append(sliResults, &keptnv2.SLIResult{
+			Metric:  baseIndicatorName,
+			Value:   0,
+			Success: false,
+			Message: err.Error(),
+		})
+	}
+
+	monitorNames := map[string]string{}
+	var monitorIDs []string
+	for _, entity := range entities.Entities {
+		if _, found := monitorNames[entity.EntityID]; !found {
+			monitorIDs = append(monitorIDs, entity.EntityID)
+		}
+		monitorNames[entity.EntityID] = entity.DisplayName
+	}
+
+	_, availabilityPass, availabilityWarning, availabilityWeight, availabilityKeySli := common.ParsePassAndWarningFromString(getTileSLOStringForKind(tileName, "availability"), []string{SyntheticAvailabilityDefaultPass}, []string{SyntheticAvailabilityDefaultWarning})
+	_, durationPass, durationWarning, durationWeight, durationKeySli := common.ParsePassAndWarningFromString(getTileSLOStringForKind(tileName, "duration"), []string{SyntheticDurationDefaultPass}, []string{SyntheticDurationDefaultWarning})
+
+	for _, monitorID := range monitorIDs {
+		monitorType := strings.Split(monitorID, "-")[0]
+		metrics, found := syntheticMonitorMetrics[monitorType]
+		if !found {
+			log.WithField("monitor", monitorID).Debug("Synthetic monitor type not supported")
+			continue
+		}
+
+		monitorName := monitorNames[monitorID]
+		if monitorName == "" {
+			monitorName = monitorID
+		}
+
+		availabilityQuery := fmt.Sprintf("metricSelector=%s:splitBy():avg&entitySelector=entityId(%s)", metrics.Availability, monitorID)
+		sliResults = append(sliResults, ph.generateSLISLOFromMetricQuery(nil, baseIndicatorName+"_availability_"+monitorName, "Percent", availabilityQuery, availabilityPass, availabilityWarning, availabilityWeight, availabilityKeySli, dashboardSLI, dashboardSLO, startUnix, endUnix)...)
+
+		durationQuery := fmt.Sprintf("metricSelector=%s:splitBy():avg&entitySelector=entityId(%s)", metrics.Duration, monitorID)
+		sliResults = append(sliResults, ph.generateSLISLOFromMetricQuery(nil, baseIndicatorName+"_duration_"+monitorName, "MilliSecond", durationQuery, durationPass, durationWarning, durationWeight, durationKeySli, dashboardSLI, dashboardSLO, startUnix, endUnix)...)
+	}
+
+	return sliResults
+}
+
+/**
+ * Queries a single metric of a synthetic monitor or entity health tile and adds the SLI & SLO definition for every split dimension value
+ */
+func (ph *Handler) generateSLISLOFromMetricQuery(splitDimensions []SplitDimension, indicatorName string, metricUnit string, metricQuery string, passSLOs []*keptncommon.SLOCriteria, warningSLOs []*keptncommon.SLOCriteria, weight int, keySli bool, dashboardSLI *SLI, dashboardSLO *keptncommon.ServiceLevelObjectives, startUnix time.Time, endUnix time.Time) []*keptnv2.SLIResult {
+	fullMetricQuery, metricID, err := ph.BuildDynatraceMetricsQuery(metricQuery, startUnix, endUnix)
+	if err != nil {
+		return []*keptnv2.SLIResult{{Metric: common.CleanIndicatorName(indicatorName), Value: 0, Success: false, Message: err.Error()}}
+	}
+
+	return ph.GenerateSLISLOFromMetricsAPIQuery(splitDimensions, indicatorName, passSLOs, warningSLOs, weight, keySli, metricID, metricUnit, metricQuery, fullMetricQuery, dashboardSLI, dashboardSLO, startUnix, endUnix)
+}
+
+// entityHealthMetric is one metric that becomes an SLI for every entity of an entity health tile
+type entityHealthMetric struct {
+	Kind           string // part of the indicator name & the prefix to overwrite its SLOs in the tile name, e.g: response_time.pass=<=500
+	Metric         string
+	Aggregation    string
+	Unit           string
+	DefaultPass    []string
+	DefaultWarning []string
+}
+
+var hostHealthMetrics = []entityHealthMetric{
+	{Kind: "cpu", Metric: "builtin:host.cpu.usage", Aggregation: "avg", Unit: "Percent", DefaultPass: []string{"<=80"}, DefaultWarning: []string{"<=90"}},
+	{Kind: "memory", Metric: "builtin:host.mem.usage", Aggregation: "avg", Unit: "Percent", DefaultPass: []string{"<=80"}, DefaultWarning: []string{"<=90"}},
+}
+
+var serviceHealthMetrics = []entityHealthMetric{
+	{Kind: "response_time", Metric: "builtin:service.response.time", Aggregation: "avg", Unit: "MicroSecond", DefaultPass: []string{"<=+10%"}, DefaultWarning: []string{"<=+25%"}},
+	{Kind: "failure_rate", Metric: "builtin:service.errors.total.rate", Aggregation: "avg", Unit: "Percent", DefaultPass: []string{"<=1"}, DefaultWarning: []string{"<=5"}},
+	{Kind: "throughput", Metric: "builtin:service.requestCount.total", Aggregation: "value", Unit: "Count"},
+}
+
+var applicationHealthMetrics = []entityHealthMetric{
+	{Kind: "apdex", Metric: "builtin:apps.web.apdex.userType", Aggregation: "avg", Unit: "NotApplicable", DefaultPass: []string{">=0.85"}, DefaultWarning: []string{">=0.7"}},
+	{Kind: "action_duration", Metric: "builtin:apps.web.visuallyComplete.load.browser", Aggregation: "avg", Unit: "MilliSecond", DefaultPass: []string{"<=+10%"}, DefaultWarning: []string{"<=+25%"}},
+	{Kind: "errors", Metric: "builtin:apps.web.countOfErrors", Aggregation: "value", Unit: "Count"},
+}
+
+/**
+ * entityHealthTiles maps the entity health tiles to the entities they show and the metrics that become SLIs for them
+ * EntitySelector selects the entities of tiles without assigned entities - e.g: the overview tiles - within the management zone of the tile
+ */
+var entityHealthTiles = map[string]struct {
+	EntitySelector  string
+	EntityDimension string
+	Metrics         []entityHealthMetric
+}{
+	"HOST":               {EntitySelector: "type(HOST)", EntityDimension: "dt.entity.host", Metrics: hostHealthMetrics},
+	"HOSTS":              {EntitySelector: "type(HOST)", EntityDimension: "dt.entity.host", Metrics: hostHealthMetrics},
+	"SERVICE_VERSATILE":  {EntitySelector: "type(SERVICE)", EntityDimension: "dt.entity.service", Metrics: serviceHealthMetrics},
+	"SERVICES":           {EntitySelector: "type(SERVICE)", EntityDimension: "dt.entity.service", Metrics: serviceHealthMetrics},
+	"DATABASE":           {EntitySelector: "type(SERVICE),serviceType(DATABASE_SERVICE)", EntityDimension: "dt.entity.service", Metrics: serviceHealthMetrics},
+	"DATABASES_OVERVIEW": {EntitySelector: "type(SERVICE),serviceType(DATABASE_SERVICE)", EntityDimension: "dt.entity.service", Metrics: serviceHealthMetrics},
+	"APPLICATION":        {EntitySelector: "type(APPLICATION)", EntityDimension: "dt.entity.application", Metrics: applicationHealthMetrics},
+	"APPLICATIONS":       {EntitySelector: "type(APPLICATION)", EntityDimension: "dt.entity.application", Metrics: applicationHealthMetrics},
+}
+
+/**
+ * Processes an entity health tile, e.g: HOST, SERVICE_VERSATILE, APPLICATION, DATABASE or their overview tiles. Every metric of the entity type becomes an SLI per entity, e.g: sli=backend -> backend_response_time, backend_failure_rate, ...
+ * If the tile shows more than one entity the entity name is added to the indicator name, e.g: backend_response_time_carts
+ * The tile name can overwrite the default SLOs per metric, e.g: sli=backend;response_time.pass=<=500;failure_rate.warning=<=2
+ */
+func (ph *Handler) ProcessEntityHealthTile(tileType string, tileName string, assignedEntities []string, tileManagementZoneFilter string, dashboardSLI *SLI, dashboardSLO *keptncommon.ServiceLevelObjectives, startUnix time.Time, endUnix time.Time) []*keptnv2.SLIResult {
+	var sliResults []*keptnv2.SLIResult
+
+	healthTile, found := entityHealthTiles[tileType]
+	if !found {
+		return sliResults
+	}
+
+	baseIndicatorName, _, _, _, _ := common.ParsePassAndWarningFromString(tileName, []string{}, []string{})
+
+	entitySelector := fmt.Sprintf("%s%s", healthTile.EntitySelector, tileManagementZoneFilter)
+	if len(assignedEntities) > 0 {
+		entitySelector = fmt.Sprintf("entityId(%s)", strings.Join(assignedEntities, ","))
+	}
+	splitDimensions := []SplitDimension{{Key: healthTile.EntityDimension, EntityDimension: true}}
+
+	for _, metric := range healthTile.Metrics {
+		_, passSLOs, warningSLOs, weight, keySli := common.ParsePassAndWarningFromString(getTileSLOStringForKind(tileName, metric.Kind), metric.DefaultPass, metric.DefaultWarning)
+
+		metricQuery := fmt.Sprintf("metricSelector=%s:splitBy(\"%s\"):%s:names&entitySelector=%s", metric.Metric, healthTile.EntityDimension, metric.Aggregation, entitySelector)
+		sliResults = append(sliResults, ph.generateSLISLOFromMetricQuery(splitDimensions, baseIndicatorName+"_"+metric.Kind, metric.Unit, metricQuery, passSLOs, warningSLOs, weight, keySli, dashboardSLI, dashboardSLO, startUnix, endUnix)...)
+	}
+
+	return sliResults
+}
+
+/**
+ * Maps the evaluators of data explorer filter criteria to metric selector filter conditions
+ */
+var dataExplorerFilterConditions = map[string]string{
+	"EQ":       "eq",
+	"NE":       "ne",
+	"PREFIX":   "prefix",
+	"SUFFIX":   "suffix",
+	"CONTAINS": "contains",
+}
+
+/**
+ * Quotes a dimension key or value for the metric & entity selector if it contains characters that have a special meaning, e.g: Test Step -> "Test Step"
+ * Quotes and tildes within quoted values are escaped with a tilde
+ */
+func quoteSelectorValue(value string) string {
+	if !strings.ContainsAny(value, " ,()\"~:") {
+		return value
+	}
+	value = strings.Replace(value, "~", "~~", -1)
+	value = strings.Replace(value, "\"", "~\"", -1)
+	return "\"" + value + "\""
+}
+
+/**
+ * Converts a single filter criteria of a data explorer filter to a metric selector filter condition, e.g: eq(dt.entity.service,SERVICE-123)
+ */
+func getDataExplorerFilterCondition(dimension string, evaluator string, value string) (string, error) {
+	if dimension == "" {
+		return "", fmt.Errorf("Filter criteria %s %s without a dimension", evaluator, value)
+	}
+
+	switch strings.ToUpper(evaluator) {
+	case "EXISTS", "EXISTS_KEY":
+		return fmt.Sprintf("existsKey(%s)", quoteSelectorValue(dimension)), nil
+	case "NOT_EXISTS", "NOT_EXISTS_KEY":
+		return fmt.Sprintf("not(existsKey(%s))", quoteSelectorValue(dimension)), nil
+	}
+
+	condition, found := dataExplorerFilterConditions[strings.ToUpper(evaluator)]
+	if !found {
+		return "", fmt.Errorf("Unsupported filter evaluator %s for dimension %s", evaluator, dimension)
+	}
+
+	return fmt.Sprintf("%s(%s,%s)", condition, quoteSelectorValue(dimension), quoteSelectorValue(value)), nil
+}
+
+/**
+ * Combines filter conditions with the filter operator, e.g: and(eq(a,1),or(eq(b,2),eq(b,3)))
+ * A single condition is returned as is
+ */
+func combineDataExplorerFilterConditions(filterOperator string, conditions []string) (string, error) {
+	if len(conditions) == 0 {
+		return "", nil
+	}
+	if len(conditions) == 1 {
+		return conditions[0], nil
+	}
+
+	switch strings.ToUpper(filterOperator) {
+	case "", "AND":
+		return fmt.Sprintf("and(%s)", strings.Join(conditions, ",")), nil
+	case "OR":
+		return fmt.Sprintf("or(%s)", strings.Join(conditions, ",")), nil
+	default:
+		return "", fmt.Errorf("Unsupported filter operator %s", filterOperator)
+	}
+}
+
+/**
+ * Converts a data explorer filter including all its criteria and nested filters into a metric selector filter condition
+ * Returns "" if the filter has no criteria
+ */
+func getDataExplorerFilterExpression(filter NestedFilterDataExplorer) (string, error) {
+	var conditions []string
+	for _, criteria := range filter.Criteria {
+		condition, err := getDataExplorerFilterCondition(filter.Filter, criteria.Evaluator, criteria.Value)
+		if err != nil {
+			return "", err
+		}
+		conditions = append(conditions, condition)
+	}
+
+	for _, nestedFilter := range filter.NestedFilters {
+		condition, err := getDataExplorerFilterExpression(nestedFilter)
+		if err != nil {
+			return "", err
+		}
+		if condition != "" {
+			conditions = append(conditions, condition)
+		}
+	}
+
+	return combineDataExplorerFilterConditions(filter.FilterOperator, conditions)
+}
+
+/**
+ * Returns the entity IDs if the filter only selects entities by ID, e.g: dt.entity.service equals SERVICE-123 or SERVICE-456
+ * Such filters can be passed as entitySelector instead of a metric filter
+ */
+func getDataExplorerEntityIDFilter(filter NestedFilterDataExplorer) []string {
+	if !strings.HasPrefix(filter.Filter, "dt.entity.") || len(filter.NestedFilters) > 0 || len(filter.Criteria) == 0 {
+		return nil
+	}
+	if len(filter.Criteria) > 1 && strings.ToUpper(filter.FilterOperator) != "OR" {
+		return nil
+	}
+
+	var entityIDs []string
+	for _, criteria := range filter.Criteria {
+		if strings.ToUpper(criteria.Evaluator) != "EQ" {
+			return nil
+		}
+		entityIDs = append(entityIDs, criteria.Value)
+	}
+	return entityIDs
+}
+
+/**
+ * getDataExplorerFilter
+ * Converts the filterBy tree of a data explorer query into the metric selector filter and entitySelector
+ * The first entity filter that only selects entities by ID and has to apply in any case (top level AND) becomes the entitySelector - everything else is translated into a metric filter
+ * Returns
+ * #1: metric filter, e.g: :filter(and(eq(Test Step,Login),or(eq(http.method,GET),eq(http.method,POST))))
+ * #2: entitySelector, e.g: &entitySelector=entityId(SERVICE-123,SERVICE-456)
+ */
+func getDataExplorerFilter(filterBy NestedFilterDataExplorer) (string, string, error) {
+	entityFilter := ""
+	remainingFilter := filterBy
+	remainingFilter.NestedFilters = nil
+
+	topLevelAnd := strings.ToUpper(filterBy.FilterOperator) != "OR" || len(filterBy.NestedFilters)+len(filterBy.Criteria) == 1
+	for _, nestedFilter := range filterBy.NestedFilters {
+		if entityIDs := getDataExplorerEntityIDFilter(nestedFilter); topLevelAnd && entityFilter == "" && entityIDs != nil {
+			entityFilter = fmt.Sprintf("&entitySelector=entityId(%s)", strings.Join(entityIDs, ","))
+			continue
+		}
+		remainingFilter.NestedFilters = append(remainingFilter.NestedFilters, nestedFilter)
+	}
+
+	filterExpression, err := getDataExplorerFilterExpression(remainingFilter)
+	if err != nil {
+		return "", "", err
+	}
+
+	filterAggregator := ""
+	if filterExpression != "" {
+		filterAggregator = fmt.Sprintf(":filter(%s)", filterExpression)
+	}
+
+	return filterAggregator, entityFilter, nil
+}
+
+// dataExplorerAggregations maps the space & time aggregations of a data explorer query to the Metrics API v2 aggregations
+var dataExplorerAggregations = map[string]string{
+	"AVG":    "avg",
+	"COUNT":  "count",
+	"MAX":    "max",
+	"MEDIAN": "median",
+	"MIN":    "min",
+	"SUM":    "sum",
+	"VALUE":  "value",
+}
+
+// DataExplorerDefaultUnit is the metric unit of code mode queries we cant look up a metric definition for, e.g: expressions
+const DataExplorerDefaultUnit = "Unspecified"
+
+/**
+ * Returns the Metrics API aggregation for a data explorer aggregation, e.g: avg or percentile(90) for PERCENTILE_90
+ * AUTO and DEFAULT - or no aggregation - return the defaultAggregation
+ */
+func getDataExplorerAggregation(aggregation string, defaultAggregation string) (string, error) {
+	aggregation = strings.ToUpper(aggregation)
+	if aggregation == "" || aggregation == "AUTO" || aggregation == "DEFAULT" {
+		return strings.ToLower(defaultAggregation), nil
+	}
+	if strings.HasPrefix(aggregation, "PERCENTILE_") {
+		return fmt.Sprintf("percentile(%s)", strings.TrimPrefix(aggregation, "PERCENTILE_")), nil
+	}
+
+	metricAggregation, found := dataExplorerAggregations[aggregation]
+	if !found {
+		return "", fmt.Errorf("Unsupported aggregation %s", aggregation)
+	}
+	return metricAggregation, nil
+}
+
+// thresholdColors maps the colors Dynatrace offers for thresholds to the SLO result they stand for
+var thresholdColors = map[string]string{
+	"#7dc540": "pass",
+	"#f5d30f": "warning",
+	"#dc172a": "fail",
+}
+
+/**
+ * getThresholdCriteria
+ * Returns the pass & warning criteria defined by the threshold of a data explorer query - or nil if the tile has no usable threshold
+ * The threshold for the query (or for all queries) needs a green and a red rule, yellow is optional. Its values must either increase from green to red (lower is better) or decrease (higher is better)
+ * Example: green=0, yellow=500, red=1000 returns pass <500 & warning <1000. red=0, yellow=90, green=95 returns pass >=95 & warning >=90
+ */
+func getThresholdCriteria(visualConfig *VisualConfig, queryID string) ([]string, []string) {
+	if visualConfig == nil {
+		return nil, nil
+	}
+
+	// older dashboards define the thresholds as part of the global settings
+	thresholds := visualConfig.Thresholds
+	if len(thresholds) == 0 && visualConfig.Global != nil {
+		thresholds = visualConfig.Global.Thresholds
+	}
+
+	var threshold *VisualThreshold
+	for ix := range thresholds {
+		if thresholds[ix].QueryID == queryID || (thresholds[ix].QueryID == "" && threshold == nil) {
+			threshold = &thresholds[ix]
+		}
+	}
+	if threshold == nil {
+		return nil, nil
+	}
+
+	values := map[string]float64{}
+	for _, rule := range threshold.Rules {
+		if result, isKnownColor := thresholdColors[strings.ToLower(rule.Color)]; isKnownColor && rule.Value != nil {
+			values[result] = *rule.Value
+		}
+	}
+
+	pass, hasPass := values["pass"]
+	fail, hasFail := values["fail"]
+	warning, hasWarning := values["warning"]
+	if !hasPass || !hasFail || pass == fail {
+		return nil, nil
+	}
+
+	formatValue := func(value float64) string {
+		return strconv.FormatFloat(value, 'f', -1, 64)
+	}
+
+	// lower is better: every band ends where the next one starts
+	if pass < fail {
+		if !hasWarning {
+			return []string{"<" + formatValue(fail)}, nil
+		}
+		if warning < pass || warning > fail {
+			return nil, nil
+		}
+		return []string{"<" + formatValue(warning)}, []string{"<" + formatValue(fail)}
+	}
+
+	// higher is better: every band starts at its value
+	if !hasWarning {
+		return []string{">=" + formatValue(pass)}, nil
+	}
+	if warning > pass || warning < fail {
+		return nil, nil
+	}
+	return []string{">=" + formatValue(pass)}, []string{">=" + formatValue(warning)}
+}
+
+// dataExplorerQueryExpression matches code mode selectors that only combine other queries, e.g: (A / B) * 100
+var dataExplorerQueryExpression = regexp.MustCompile(`^[A-Z0-9\s+\-*/().]+$`)
+
+// dataExplorerQueryReference matches the references to other queries within an expression, e.g: A, B
+var dataExplorerQueryReference = regexp.MustCompile(`\b[A-Z]\b`)
+
+/**
+ * Returns the split dimensions of a code mode metric selector based on its last splitBy transformation, e.g: builtin:service.response.time:splitBy("dt.entity.service"):avg returns dt.entity.service
+ */
+func getSplitDimensionsFromMetricSelector(metricSelector string) []SplitDimension {
+	splitByIx := strings.LastIndex(metricSelector, "splitBy(")
+	if splitByIx < 0 {
+		return nil
+	}
+	splitBy := metricSelector[splitByIx+len("splitBy("):]
+	splitBy = splitBy[:strings.Index(splitBy+")", ")")]
+
+	var splitDimensions []SplitDimension
+	for _, dimension := range strings.Split(splitBy, ",") {
+		dimension = strings.Trim(strings.TrimSpace(dimension), "\"")
+		if dimension != "" {
+			splitDimensions = append(splitDimensions, newSplitDimension(dimension, ""))
+		}
+	}
+	return splitDimensions
+}
+
+/**
+ * Returns the metric key a metric selector starts with, e.g: builtin:service.response.time for builtin:service.response.time:splitBy():avg
+ * The prefixes of builtin, calculated, extension & function metrics contain a colon themselves
+ */
+func getMetricKeyFromMetricSelector(metricSelector string) string {
+	selectorParts := strings.Split(metricSelector, ":")
+	switch selectorParts[0] {
+	case "builtin", "calc", "ext", "func", "dsfm":
+		if len(selectorParts) > 1 {
+			return selectorParts[0] + ":" + selectorParts[1]
+		}
+	}
+	return selectorParts[0]
+}
+
+/**
+ * Generates the metric selector of a single data explorer query
+ * inlineEntityFilter: entity filters become part of the metric selector instead of an entitySelector - needed if the selector is part of an expression
+ * Returns
+ * #1: metricSelector, e.g: jmeter.usermetrics.transaction.meantime:merge(0):filter(eq(transaction,login)):avg
+ * #2: metricUnit, e.g: MilliSeconds
+ * #3: entityFilter, e.g: &entitySelector=entityId(SERVICE-123)
+ * #4: splitDimensions, e.g: Test Step, dt.entity.service
+ */
+func (ph *Handler) getDataExplorerMetricSelector(dataQuery DataExplorerQuery, inlineEntityFilter bool) (string, string, string, []SplitDimension, error) {
+
+	// code mode: we take the selector as written
+	if dataQuery.MetricSelector != "" {
+		metricUnit := DataExplorerDefaultUnit
+		metricKey := getMetricKeyFromMetricSelector(dataQuery.MetricSelector)
+		if metricDefinition, err := ph.ExecuteMetricAPIDescribe(metricKey); err == nil {
+			metricUnit = metricDefinition.Unit
+		} else {
+			log.WithError(err).WithField("metric", metricKey).Debug("Couldnt retrieve metric description for code mode query - using default unit")
+		}
+		return dataQuery.MetricSelector, metricUnit, "", getSplitDimensionsFromMetricSelector(dataQuery.MetricSelector), nil
+	}
+
+	// Lets query the metric definition as we need to know how many dimension the metric has
+	metricDefinition, err := ph.ExecuteMetricAPIDescribe(dataQuery.Metric)
+	if err != nil {
+		log.WithError(err).WithField("metric", dataQuery.Metric).Debug("Error retrieving metric description")
+		return "", "", "", nil, err
+	}
+
+	// building the merge aggregator string, e.g: merge(1):merge(0) - or merge(0)
+	metricDimensionCount := len(metricDefinition.DimensionDefinitions)
+	mergeAggregator := ""
+	filterAggregator := ""
+	entityFilter := ""
+	var splitDimensions []SplitDimension
+
+	// we need to merge all those dimensions based on the metric definition that are not included in the "splitBy"
+	// so - we iterate through the dimensions based on the metric definition from the back to front - and then merge those not included in splitBy
+	for metricDimIx := metricDimensionCount - 1; metricDimIx >= 0; metricDimIx-- {
+		log.WithField("metricDimIx", metricDimIx).Debug("Processing Dimension Ix")
+
+		doMergeDimension := true
+		for _, splitDimension := range dataQuery.SplitBy {
+			log.WithFields(
+				log.Fields{
+					"dimension1": splitDimension,
+					"dimension2": metricDefinition.DimensionDefinitions[metricDimIx].Key,
+				}).Debug("Comparing Dimensions %")
+
+			if strings.Compare(splitDimension, metricDefinition.DimensionDefinitions[metricDimIx].Key) == 0 {
+				doMergeDimension = false
+			}
+		}
+
+		// if we split by a dimension we need to include that dimension in our individual SLI query definitions - thats why we hand it back
+		// as we iterate from back to front we prepend it to keep the order of the metric definition
+		if !doMergeDimension {
+			splitDimensions = append([]SplitDimension{newSplitDimension(metricDefinition.DimensionDefinitions[metricDimIx].Key, metricDefinition.DimensionDefinitions[metricDimIx].Type)}, splitDimensions...)
+		}
+
+		if doMergeDimension {
+			// this is a dimension we want to merge as it is not split by in the chart
+			log.WithField("dimension", metricDefinition.DimensionDefinitions[metricDimIx].Key).Debug("merging dimension")
+			mergeAggregator = mergeAggregator + fmt.Sprintf(":merge(%d)", metricDimIx)
+		}
+	}
+
+	// Create the right metric filter & entity Selectors for the queries execute
+	// as both are part of the metricQuery they are also part of every SLI definition we generate from it
+	if dataQuery.FilterBy != nil {
+		if inlineEntityFilter {
+			var filterExpression string
+			filterExpression, err = getDataExplorerFilterExpression(*dataQuery.FilterBy)
+			if filterExpression != "" {
+				filterAggregator = fmt.Sprintf(":filter(%s)", filterExpression)
+			}
+		} else {
+			filterAggregator, entityFilter, err = getDataExplorerFilter(*dataQuery.FilterBy)
+		}
+		if err != nil {
+			log.WithError(err).WithField("metric", dataQuery.Metric).Debug("Error processing data explorer filter")
+			return "", "", "", nil, err
+		}
+	}
+
+	// the space aggregation aggregates the split dimensions, the time aggregation folds all values of the timeframe into one
+	spaceAggregation, err := getDataExplorerAggregation(dataQuery.SpaceAggregation, metricDefinition.DefaultAggregation.Type)
+	if err != nil {
+		return "", "", "", nil, fmt.Errorf("Data explorer query %s on metric %s: %v", dataQuery.ID, dataQuery.Metric, err)
+	}
+	timeAggregation, err := getDataExplorerAggregation(dataQuery.TimeAggregation, "")
+	if err != nil {
+		return "", "", "", nil, fmt.Errorf("Data explorer query %s on metric %s: %v", dataQuery.ID, dataQuery.Metric, err)
+	}
+
+	metricSelector := fmt.Sprintf("%s%s%s:%s", dataQuery.Metric, mergeAggregator, filterAggregator, spaceAggregation)
+	if timeAggregation != "" {
+		metricSelector = fmt.Sprintf("%s:fold(%s)", metricSelector, timeAggregation)
+	}
+
+	return metricSelector, metricDefinition.Unit, entityFilter, splitDimensions, nil
+}
+
+/**
+ * Looks at the DataExplorerQuery configuration of a data explorer chart and generates the Metrics Query
+ * queries: all queries of the tile - code mode expressions can reference them by their ID
+ * resolution: the resolution of the tile. Empty means we use one value for the whole timeframe
+ * Returns
+ * #1: metricId, e.g: built-in:mymetric
+ * #2: metricUnit, e.g: MilliSeconds
  * #3: metricQuery, e.g: metricSelector=metric&filter...
  * #4: fullMetricQuery, e.g: metricQuery&from=123213&to=2323
- * #5: entitySelectirSLIDefinition, e.g: ,entityid(FILTERDIMENSIONVALUE)
- * #6: filterSLIDefinitionAttregator, e.g: , filter(eq(Test Step,FILTERDIMENSIONVALUE))
+ * #5: splitDimensions, e.g: Test Step, dt.entity.service
  */
-func (ph *Handler) GenerateMetricQueryFromChart(series ChartSeries, tileManagementZoneFilter string, filtersPerEntityType map[string]map[string][]string, startUnix time.Time, endUnix time.Time) (string, string, string, string, string, string, error) {
+func (ph *Handler) GenerateMetricQueryFromDataExplorer(dataQuery DataExplorerQuery, queries []DataExplorerQuery, resolution string, tileManagementZoneFilter string, startUnix time.Time, endUnix time.Time) (string, string, string, string, []SplitDimension, error) {
+
+	var metricSelector, metricUnit, entityFilter string
+	var splitDimensions []SplitDimension
+	var err error
+
+	if dataQuery.MetricSelector != "" && dataExplorerQueryExpression.MatchString(dataQuery.MetricSelector) {
+		// an expression of other queries, e.g: (A / B) * 100 - we replace every reference with the selector of that query
+		metricUnit = DataExplorerDefaultUnit
+		metricSelector = dataExplorerQueryReference.ReplaceAllStringFunc(dataQuery.MetricSelector, func(queryID string) string {
+			if err != nil {
+				return queryID
+			}
+			for _, referencedQuery := range queries {
+				if referencedQuery.ID != queryID || referencedQuery.ID == dataQuery.ID {
+					continue
+				}
+				referencedSelector, _, _, referencedSplitDimensions, referenceErr := ph.getDataExplorerMetricSelector(referencedQuery, true)
+				if referenceErr != nil {
+					err = referenceErr
+					return queryID
+				}
+				if splitDimensions == nil {
+					splitDimensions = referencedSplitDimensions
+				}
+				return "(" + referencedSelector + ")"
+			}
+			err = fmt.Errorf("Data explorer query %s references unknown query %s", dataQuery.ID, queryID)
+			return queryID
+		})
+		metricSelector = "(" + metricSelector + ")"
+	} else {
+		metricSelector, metricUnit, entityFilter, splitDimensions, err = ph.getDataExplorerMetricSelector(dataQuery, false)
+	}
+	if err != nil {
+		return "", "", "", "", nil, err
+	}
+
+	// the management zone can only be added to an existing entitySelector - otherwise we use the mzSelector
+	managementZoneFilter := tileManagementZoneFilter
+	if entityFilter == "" && tileManagementZoneFilter != "" {
+		managementZoneFilter = "&mzSelector=" + strings.TrimPrefix(tileManagementZoneFilter, ",")
+	}
+
+	// lets create the metricSelector and entitySelector
+	// ATTENTION: adding :names so we also get the names of the dimensions and not just the entities. This means we get two values for each dimension
+	if !strings.Contains(metricSelector, ":names") {
+		metricSelector = metricSelector + ":names"
+	}
+	// the selector is escaped as expressions can contain a +, e.g: (A + B) which would otherwise become a space
+	metricQuery := fmt.Sprintf("metricSelector=%s%s%s", queryValueEscaper.Replace(metricSelector), entityFilter, managementZoneFilter)
+
+	// the resolution is part of the metric query so that every SLI definition is evaluated with the same resolution as the tile
+	if resolution != "" && !strings.EqualFold(resolution, "auto") {
+		metricQuery = fmt.Sprintf("%s&resolution=%s", metricQuery, resolution)
+	}
+
+	// lets build the Dynatrace API Metric query for the proposed timeframe and additonal filters!
+	fullMetricQuery, metricID, err := ph.BuildDynatraceMetricsQuery(metricQuery, startUnix, endUnix)
+	if err != nil {
+		return "", "", "", "", nil, err
+	}
+
+	return metricID, metricUnit, metricQuery, fullMetricQuery, splitDimensions, nil
+}
+
+/**
+ * Looks at the ChartSeries configuration of a regular chart and generates the Metrics Query
+ * Returns
+ * #1: metricId, e.g: built-in:mymetric
+ * #2: metricUnit, e.g: MilliSeconds, Percent,ratio=other for the OTHER_RATIO aggregation or Count,rate=minute for the MINUTE aggregation rate
+ * #3: metricQuery, e.g: metricSelector=metric&filter...
+ * #4: fullMetricQuery, e.g: metricQuery&from=123213&to=2323
+ * #5: splitDimensions, e.g: Test Step, dt.entity.service
+ */
+func (ph *Handler) GenerateMetricQueryFromChart(series ChartSeries, tileManagementZoneFilter string, filtersPerEntityType map[string]map[string][]string, startUnix time.Time, endUnix time.Time) (string, string, string, string, []SplitDimension, error) {
 	// Lets query the metric definition as we need to know how many dimension the metric has
 	metricDefinition, err := ph.ExecuteMetricAPIDescribe(series.Metric)
 	if err != nil {
 		log.WithError(err).WithField("metric", series.Metric).Debug("Error retrieving metric description")
-		return "", "", "", "", "", "", err
+		return "", "", "", "", nil, err
+	}
+
+	// building the merge aggregator string, e.g: merge(1):merge(0) - or merge(0)
+	metricDimensionCount := len(metricDefinition.DimensionDefinitions)
+	metricAggregation := metricDefinition.DefaultAggregation.Type
+	mergeAggregator := ""
+	filterAggregator := ""
+	var dimensionConditions []string
+	var splitDimensions []SplitDimension
+
+	// now we need to merge all the dimensions that are not part of the series.dimensions, e.g: if the metric has two dimensions but only one dimension is used in the chart we need to merge the others
+	// as multiple-merges are possible but as they are executed in sequence we have to use the right index
+	for metricDimIx := metricDimensionCount - 1; metricDimIx >= 0; metricDimIx-- {
+		doMergeDimension := true
+		metricDimIxAsString := strconv.Itoa(metricDimIx)
+		// lets check if this dimension is in the chart
+		for _, seriesDim := range series.Dimensions {
+			log.WithFields(
+				log.Fields{
+					"seriesDim.id": seriesDim.ID,
+					"metricDimIx":  metricDimIxAsString,
+				}).Debug("check")
+			if strings.Compare(seriesDim.ID, metricDimIxAsString) == 0 {
+				// this is a dimension we want to keep and not merge
+				log.WithField("dimension", metricDefinition.DimensionDefinitions[metricDimIx].Name).Debug("not merging dimension")
+				doMergeDimension = false
+
+				// lets check if we need to apply a dimension filter - every selected value becomes an eq - all of them or'ed
+				if len(seriesDim.Values) > 0 {
+					dimensionKey := metricDefinition.DimensionDefinitions[metricDimIx].Key
+					if dimensionKey == "" {
+						dimensionKey = seriesDim.Name
+					}
+					var valueConditions []string
+					for _, value := range seriesDim.Values {
+						valueConditions = append(valueConditions, fmt.Sprintf("eq(%s,%s)", quoteSelectorValue(dimensionKey), quoteSelectorValue(value)))
+					}
+					valueCondition, _ := combineDataExplorerFilterConditions("OR", valueConditions)
+					// as we iterate from back to front we prepend it to keep the order of the metric definition
+					dimensionConditions = append([]string{valueCondition}, dimensionConditions...)
+				} else {
+					// we need this for the generation of the SLI for each individual dimension value
+					// as we iterate from back to front we prepend it to keep the order of the metric definition
+					splitDimension := newSplitDimension(metricDefinition.DimensionDefinitions[metricDimIx].Key, metricDefinition.DimensionDefinitions[metricDimIx].Type)
+					splitDimension.EntityDimension = splitDimension.EntityDimension || seriesDim.EntityDimension
+					splitDimensions = append([]SplitDimension{splitDimension}, splitDimensions...)
+				}
+			}
+		}
+
+		if doMergeDimension {
+			// this is a dimension we want to merge as it is not split by in the chart
+			log.WithField("dimension", metricDefinition.DimensionDefinitions[metricDimIx].Name).Debug("merging dimension")
+			mergeAggregator = mergeAggregator + fmt.Sprintf(":merge(%d)", metricDimIx)
+		}
+	}
+
+	// filters on multiple dimensions all have to match
+	if len(dimensionConditions) > 0 {
+		filterCondition, _ := combineDataExplorerFilterConditions("AND", dimensionConditions)
+		filterAggregator = fmt.Sprintf(":filter(%s)", filterCondition)
+	}
+
+	// handle aggregation. If "NONE" is specified we go to the defaultAggregration
+	metricUnit := metricDefinition.Unit
+	if series.Aggregation != "NONE" && series.Aggregation != "" {
+		metricAggregation = series.Aggregation
+	}
+	metricAggregation, metricUnit, err = getChartMetricAggregation(metricAggregation, series.Percentile, metricUnit, metricDefinition.AggregationTypes)
+	if err != nil {
+		return "", "", "", "", nil, fmt.Errorf("Chart series for metric %s: %v", series.Metric, err)
+	}
+
+	// handle aggregation rates, e.g: requests per minute. We normalize the value of the timeframe to that rate
+	metricUnit, err = getChartMetricUnitWithRate(series.AggregationRate, metricAggregation, metricUnit)
+	if err != nil {
+		return "", "", "", "", nil, fmt.Errorf("Chart series for metric %s: %v", series.Metric, err)
+	}
+
+	// lets get the true entity type as the one in the dashboard might not be accurate, e.g: IOT might be used instead of CUSTOM_DEVICE
+	// so - if the metric definition has EntityTypes defined we take the first one
+	entityType := series.EntityType
+	if len(metricDefinition.EntityType) > 0 {
+		entityType = metricDefinition.EntityType[0]
+	}
+
+	// Need to implement chart filters per entity type, e.g: its possible that a chart has a filter on entites or tags
+	// lets see if we have a FiltersPerEntityType for the tiles EntityType
+	entityTileFilter := ph.GetEntitySelectorFromEntityFilter(filtersPerEntityType, entityType)
+
+	// lets create the metricSelector and entitySelector
+	// ATTENTION: adding :names so we also get the names of the dimensions and not just the entities. This means we get two values for each dimension
+	metricQuery := fmt.Sprintf("metricSelector=%s%s%s:%s:names&entitySelector=type(%s)%s%s",
+		series.Metric, mergeAggregator, filterAggregator, metricAggregation,
+		entityType, entityTileFilter, tileManagementZoneFilter)
+
+	// lets build the Dynatrace API Metric query for the proposed timeframe and additonal filters!
+	fullMetricQuery, metricID, err := ph.BuildDynatraceMetricsQuery(metricQuery, startUnix, endUnix)
+	if err != nil {
+		return "", "", "", "", nil, err
+	}
+
+	return metricID, metricUnit, metricQuery, fullMetricQuery, splitDimensions, nil
+}
+
+// chartAggregations maps the aggregations of a chart series to the Metrics API v2 aggregations
+var chartAggregations = map[string]string{
+	"AUTO":       "auto",
+	"AVG":        "avg",
+	"COUNT":      "count",
+	"MAX":        "max",
+	"MEDIAN":     "median",
+	"MIN":        "min",
+	"PERCENTILE": "percentile",
+	"SUM":        "sum",
+	"VALUE":      "value",
+	// for rate measures such as failure rate the chart shows the average of the rate
+	"OF_INTEREST_RATIO": "avg",
+	// OTHER_RATIO is the inverse of OF_INTEREST_RATIO. The API doesnt support it - so - we query the avg and calculate the inverse
+	"OTHER_RATIO": "avg",
+}
+
+// MetricUnitOptionOtherRatio marks a metric unit of an MV2 query whose value has to be inverted, e.g: MV2;Percent,ratio=other;metricSelector=...
+const MetricUnitOptionOtherRatio = "ratio=other"
+
+// MetricUnitOptionRate normalizes the value of an MV2 query to a rate, e.g: MV2;Count,rate=minute;metricSelector=... returns the count per minute of the timeframe
+const MetricUnitOptionRate = "rate="
+
+// chartAggregationRates are the rates a chart series can show its values in
+var chartAggregationRates = map[string]time.Duration{
+	"SECOND": time.Second,
+	"MINUTE": time.Minute,
+	"HOUR":   time.Hour,
+}
+
+/**
+ * Returns the metric unit including the rate option for the aggregation rate of a chart series, e.g: Count,rate=minute
+ * TOTAL - the default - doesnt need an option as we query the total of the timeframe anyway
+ * A rate only makes sense for values that add up over the timeframe - for all other aggregations, e.g: avg or max, the aggregation rate is ignored
+ */
+func getChartMetricUnitWithRate(aggregationRate string, metricAggregation string, metricUnit string) (string, error) {
+	if aggregationRate == "" || strings.EqualFold(aggregationRate, "TOTAL") {
+		return metricUnit, nil
+	}
+	if _, found := chartAggregationRates[strings.ToUpper(aggregationRate)]; !found {
+		return "", fmt.Errorf("Unsupported aggregation rate %s", aggregationRate)
+	}
+
+	if !isRateAggregation(metricAggregation, metricUnit) {
+		log.WithFields(
+			log.Fields{
+				"aggregationRate": aggregationRate,
+				"aggregation":     metricAggregation,
+			}).Info("Ignoring aggregation rate as the aggregation doesnt add up over the timeframe")
+		return metricUnit, nil
+	}
+
+	return metricUnit + "," + MetricUnitOptionRate + strings.ToLower(aggregationRate), nil
+}
+
+/**
+ * Returns whether values of an aggregation add up over the timeframe and can therefore be normalized to a rate: count, sum and value of a count
+ */
+func isRateAggregation(metricAggregation string, metricUnit string) bool {
+	switch strings.ToLower(metricAggregation) {
+	case "count", "sum":
+		return true
+	case "value":
+		return strings.EqualFold(strings.Split(metricUnit, ",")[0], "Count")
+	}
+	return false
+}
+
+/**
+ * Returns the last aggregation of a metric selector, e.g: sum for builtin:service.requestCount.total:merge(0):sum:names
+ * Returns an empty string if the metric selector doesnt specify an aggregation
+ */
+func getMetricSelectorAggregation(metricSelector string) string {
+	transformations := strings.Split(metricSelector, ":")
+	for transformationIx := len(transformations) - 1; transformationIx > 0; transformationIx-- {
+		transformation := strings.ToLower(strings.SplitN(transformations[transformationIx], "(", 2)[0])
+		for _, aggregation := range chartAggregations {
+			if transformation == aggregation {
+				return transformation
+			}
+		}
+	}
+	return ""
+}
+
+/**
+ * Normalizes the value of the timeframe to the rate option of the metric unit, e.g: a count of 600 for a 10 minute timeframe is 60 for Count,rate=minute
+ * Returns the value as is if the metric unit has no rate option or if the metric selector uses an aggregation that doesnt add up over the timeframe, e.g: avg
+ */
+func normalizeDataToRate(metricID string, unit string, value float64, startUnix time.Time, endUnix time.Time) float64 {
+	for _, unitOption := range strings.Split(unit, ",")[1:] {
+		if !strings.HasPrefix(unitOption, MetricUnitOptionRate) {
+			continue
+		}
+
+		rate, found := chartAggregationRates[strings.ToUpper(strings.TrimPrefix(unitOption, MetricUnitOptionRate))]
+		timeframe := endUnix.Sub(startUnix)
+		if !found || timeframe <= 0 {
+			log.WithField("unit", unit).Debug("Cant normalize value to rate - returning it as is")
+			return value
+		}
+
+		if aggregation := getMetricSelectorAggregation(metricID); aggregation != "" && !isRateAggregation(aggregation, unit) {
+			log.WithFields(
+				log.Fields{
+					"unit":        unit,
+					"aggregation": aggregation,
+				}).Info("Ignoring rate as the aggregation doesnt add up over the timeframe - returning the value as is")
+			return value
+		}
+
+		return value * float64(rate) / float64(timeframe)
+	}
+
+	return value
+}
+
+// MetricUnitOptionTimeframe evaluates an MV2 query on a timeframe ending with the evaluation, e.g: MV2;Count,timeframe=-2h;metricSelector=... queries the last 2 hours
+const MetricUnitOptionTimeframe = "timeframe="
+
+// MetricUnitOptionShift evaluates an MV2 query on the shifted evaluation timeframe, e.g: MV2;Count,shift=-7d;metricSelector=... queries the same timeframe a week earlier
+const MetricUnitOptionShift = "shift="
+
+// MetricUnitOptionReference divides the value of an MV2 query by its value on the shifted timeframe, e.g: MV2;Percent,reference=-7d;metricSelector=... returns the current divided by last week's value
+const MetricUnitOptionReference = "reference="
+
+// relativeTimeframeExpression matches relative timeframes as used by dashboards & tiles, e.g: -2h, now-7d or -30m to now
+var relativeTimeframeExpression = regexp.MustCompile(`^(?:now)?-(\d+)([mhdw])(?: to now)?$`)
+
+var relativeTimeframeUnits = map[string]time.Duration{
+	"m": time.Minute,
+	"h": time.Hour,
+	"d": 24 * time.Hour,
+	"w": 7 * 24 * time.Hour,
+}
+
+/**
+ * Parses a relative timeframe, e.g: -2h, now-7d or -30m to now
+ * Returns the timeframe in its short form, e.g: -7d, and its offset, e.g: -168h
+ */
+func parseRelativeTimeframe(timeframe string) (string, time.Duration, error) {
+	matches := relativeTimeframeExpression.FindStringSubmatch(strings.TrimSpace(timeframe))
+	if matches == nil {
+		return "", 0, fmt.Errorf("Unsupported timeframe %s - use a relative timeframe such as -2h, -7d or -1w", timeframe)
+	}
+
+	amount, _ := strconv.Atoi(matches[1])
+	return "-" + matches[1] + matches[2], -time.Duration(amount) * relativeTimeframeUnits[matches[2]], nil
+}
+
+// getMetricUnitOption returns the value of an option of the metric unit, e.g: -7d for shift= of Count,shift=-7d
+func getMetricUnitOption(unit string, option string) (string, bool) {
+	for _, unitOption := range strings.Split(unit, ",")[1:] {
+		if strings.HasPrefix(unitOption, option) {
+			return strings.TrimPrefix(unitOption, option), true
+		}
+	}
+
+	return "", false
+}
+
+/**
+ * Returns the timeframe an MV2 query is evaluated on based on the timeframe= or shift= option of its metric unit
+ * Returns the evaluation timeframe if the metric unit has neither
+ */
+func getMetricUnitTimeframe(unit string, startUnix time.Time, endUnix time.Time) (time.Time, time.Time, error) {
+	if timeframe, found := getMetricUnitOption(unit, MetricUnitOptionTimeframe); found {
+		_, offset, err := parseRelativeTimeframe(timeframe)
+		if err != nil {
+			return startUnix, endUnix, err
+		}
+		return endUnix.Add(offset), endUnix, nil
+	}
+
+	if shift, found := getMetricUnitOption(unit, MetricUnitOptionShift); found {
+		_, offset, err := parseRelativeTimeframe(shift)
+		if err != nil {
+			return startUnix, endUnix, err
+		}
+		return startUnix.Add(offset), endUnix.Add(offset), nil
+	}
+
+	return startUnix, endUnix, nil
+}
+
+/**
+ * Returns the Metrics API aggregation for the aggregation of a chart series, e.g: avg or percentile(90.000000), and the metric unit including the options needed to calculate the value the chart shows
+ * Returns an error for aggregations we cant translate or that the metric doesnt support
+ */
+func getChartMetricAggregation(aggregation string, percentile interface{}, metricUnit string, supportedAggregationTypes []string) (string, string, error) {
+	metricAggregation, found := chartAggregations[strings.ToUpper(aggregation)]
+	if !found {
+		return "", "", fmt.Errorf("Unsupported aggregation %s", aggregation)
+	}
+
+	if len(supportedAggregationTypes) > 0 {
+		supported := false
+		for _, supportedAggregationType := range supportedAggregationTypes {
+			if strings.EqualFold(supportedAggregationType, metricAggregation) {
+				supported = true
+				break
+			}
+		}
+		if !supported {
+			return "", "", fmt.Errorf("Aggregation %s is not supported by the metric. Supported aggregations: %s", aggregation, strings.Join(supportedAggregationTypes, ","))
+		}
+	}
+
+	// for percentile we need to specify the percentile itself
+	if metricAggregation == "percentile" {
+		percentileValue, ok := percentile.(float64)
+		if !ok {
+			return "", "", fmt.Errorf("Aggregation %s without a valid percentile: %v", aggregation, percentile)
+		}
+		metricAggregation = fmt.Sprintf("%s(%f)", metricAggregation, percentileValue)
+	}
+
+	if strings.EqualFold(aggregation, "OTHER_RATIO") {
+		metricUnit = metricUnit + "," + MetricUnitOptionOtherRatio
+	}
+
+	return metricAggregation, metricUnit, nil
+}
+
+// SplitDimension is a dimension a chart or data explorer tile splits its metric by. Each value of it becomes its own SLI
+type SplitDimension struct {
+	Key string
+	// EntityDimension is true for dt.entity.* dimensions. With :names the Metrics API returns the entity name followed by the entity ID for them
+	EntityDimension bool
+}
+
+/**
+ * Creates a SplitDimension for a dimension key & the dimension type of the metric definition, e.g: ENTITY, STRING
+ */
+func newSplitDimension(key string, dimensionType string) SplitDimension {
+	return SplitDimension{
+		Key:             key,
+		EntityDimension: strings.EqualFold(dimensionType, "ENTITY") || strings.HasPrefix(key, "dt.entity."),
+	}
+}
+
+/**
+ * Returns the display names & the values (for entities the entity ID) of the split dimensions of a single data entry
+ * We prefer the dimensionMap. If that isnt available we fall back to the dimensions list which - because of :names - contains name & ID for every entity dimension
+ * Returns nil values if the data entry doesnt match the split dimensions
+ */
+func getSplitDimensionValues(splitDimensions []SplitDimension, singleDataEntry MetricQueryResultNumbers) ([]string, []string) {
+	if len(splitDimensions) == 0 {
+		return nil, nil
+	}
+
+	var names, values []string
+
+	// Option 1: the dimensionMap contains all split dimensions
+	foundAllInMap := len(singleDataEntry.DimensionMap) > 0
+	for _, splitDimension := range splitDimensions {
+		value, found := singleDataEntry.DimensionMap[splitDimension.Key]
+		if !found {
+			foundAllInMap = false
+			break
+		}
+		name := value
+		if displayName, found := singleDataEntry.DimensionMap[splitDimension.Key+".name"]; found && splitDimension.EntityDimension {
+			name = displayName
+		}
+		names = append(names, name)
+		values = append(values, value)
+	}
+	if foundAllInMap {
+		return names, values
+	}
+
+	// Option 2: the dimensions list. For entity dimensions we either get name & ID or just the ID
+	entityDimensionCount := 0
+	for _, splitDimension := range splitDimensions {
+		if splitDimension.EntityDimension {
+			entityDimensionCount++
+		}
+	}
+
+	dimensionCount := len(singleDataEntry.Dimensions)
+	if dimensionCount != len(splitDimensions) && dimensionCount != len(splitDimensions)+entityDimensionCount {
+		return nil, nil
+	}
+
+	names, values = nil, nil
+	dimIx := 0
+	for _, splitDimension := range splitDimensions {
+		name := singleDataEntry.Dimensions[dimIx]
+		value := name
+		if splitDimension.EntityDimension && dimensionCount != len(splitDimensions) {
+			value = singleDataEntry.Dimensions[dimIx+1]
+			dimIx++
+		}
+		dimIx++
+		names = append(names, name)
+		values = append(values, value)
+	}
+
+	return names, values
+}
+
+/**
+ * Returns the dimension names for the indicator name & the metric query that filters on exactly the split dimension values of the data entry, e.g:
+ * metricSelector=jmeter.usermetrics.transaction.meantime:avg:names&entitySelector=type(SERVICE) for the split dimensions transaction & dt.entity.service becomes
+ * metricSelector=jmeter.usermetrics.transaction.meantime:avg:names:filter(eq(transaction,"4 - Random Search"))&entitySelector=type(SERVICE),entityId(SERVICE-123)
+ * An entity dimension becomes the entityId of the entitySelector - only if the entitySelector selects another entity type it becomes an eq filter like all other dimensions
+ * If the split dimension values cant be matched we keep the query as is and use all dimensions of the data entry for the indicator name
+ */
+func getSLIDefinitionForSplitDimensions(splitDimensions []SplitDimension, singleDataEntry MetricQueryResultNumbers, metricQuery string) ([]string, string) {
+	names, values := getSplitDimensionValues(splitDimensions, singleDataEntry)
+	if names == nil {
+		log.WithFields(
+			log.Fields{
+				"dimensions":      singleDataEntry.Dimensions,
+				"splitDimensions": splitDimensions,
+			}).Debug("Couldnt match dimensions with split dimensions - not adding a filter to the SLI definition")
+		return singleDataEntry.Dimensions, metricQuery
 	}
 
-	// building the merge aggregator string, e.g: merge(1):merge(0) - or merge(0)
-	metricDimensionCount := len(metricDefinition.DimensionDefinitions)
-	metricAggregation := metricDefinition.DefaultAggregation.Type
-	mergeAggregator := ""
-	filterAggregator := ""
-	filterSLIDefinitionAggregator := ""
-	entitySelectorSLIDefinition := ""
+	parameters := parseMetricQueryParameters(metricQuery)
 
-	// now we need to merge all the dimensions that are not part of the series.dimensions, e.g: if the metric has two dimensions but only one dimension is used in the chart we need to merge the others
-	// as multiple-merges are possible but as they are executed in sequence we have to use the right index
-	for metricDimIx := metricDimensionCount - 1; metricDimIx >= 0; metricDimIx-- {
-		doMergeDimension := true
-		metricDimIxAsString := strconv.Itoa(metricDimIx)
-		// lets check if this dimension is in the chart
-		for _, seriesDim := range series.Dimensions {
+	var filterConditions []string
+	entityIDAdded := false
+	for ix, splitDimension := range splitDimensions {
+		if splitDimension.EntityDimension && !entityIDAdded {
+			entitySelector, added := addEntityIDToEntitySelector(getMetricQueryParameter(parameters, "entitySelector"), values[ix])
+			if added {
+				parameters = setMetricQueryParameter(parameters, "entitySelector", queryValueEscaper.Replace(entitySelector))
+				entityIDAdded = true
+				continue
+			}
 			log.WithFields(
 				log.Fields{
-					"seriesDim.id": seriesDim.ID,
-					"metricDimIx":  metricDimIxAsString,
-				}).Debug("check")
-			if strings.Compare(seriesDim.ID, metricDimIxAsString) == 0 {
-				// this is a dimension we want to keep and not merge
-				log.WithField("dimension", metricDefinition.DimensionDefinitions[metricDimIx].Name).Debug("not merging dimension")
-				doMergeDimension = false
-
-				// lets check if we need to apply a dimension filter
-				// TODO: support multiple filters - right now we only support 1
-				if len(seriesDim.Values) > 0 {
-					filterAggregator = fmt.Sprintf(":filter(eq(%s,%s))", seriesDim.Name, seriesDim.Values[0])
-				} else {
-					// we need this for the generation of the SLI for each individual dimension value
-					// if the dimension is a dt.entity we have to add an addiotnal entityId to the entitySelector - otherwise we add a filter for the dimension
-					if strings.HasPrefix(seriesDim.Name, "dt.entity.") {
-						entitySelectorSLIDefinition = fmt.Sprintf(",entityId(FILTERDIMENSIONVALUE)")
-					} else {
-						filterSLIDefinitionAggregator = fmt.Sprintf(":filter(eq(%s,FILTERDIMENSIONVALUE))", seriesDim.Name)
-					}
-				}
-			}
+					"entityId":       values[ix],
+					"entitySelector": entitySelector,
+				}).Debug("entitySelector selects another entity type - filtering the entity with eq()")
 		}
 
-		if doMergeDimension {
-			// this is a dimension we want to merge as it is not split by in the chart
-			log.WithField("dimension", metricDefinition.DimensionDefinitions[metricDimIx].Name).Debug("merging dimension")
-			mergeAggregator = mergeAggregator + fmt.Sprintf(":merge(%d)", metricDimIx)
-		}
+		filterConditions = append(filterConditions, fmt.Sprintf("eq(%s,%s)", quoteSelectorValue(splitDimension.Key), quoteSelectorValue(values[ix])))
 	}
 
-	// handle aggregation. If "NONE" is specified we go to the defaultAggregration
-	if series.Aggregation != "NONE" {
-		metricAggregation = series.Aggregation
+	if len(filterConditions) > 0 {
+		filterCondition, _ := combineDataExplorerFilterConditions("AND", filterConditions)
+		metricSelector := getMetricQueryParameter(parameters, "metricSelector")
+		metricSelector = strings.Replace(metricSelector, ":names", ":names:filter("+filterCondition+")", 1)
+		parameters = setMetricQueryParameter(parameters, "metricSelector", queryValueEscaper.Replace(metricSelector))
 	}
-	// for percentile we need to specify the percentile itself
-	if metricAggregation == "PERCENTILE" {
-		metricAggregation = fmt.Sprintf("%s(%f)", metricAggregation, series.Percentile)
+
+	return names, encodeMetricQueryParameters(parameters)
+}
+
+/**
+ * Narrows an entitySelector down to a single entity, e.g: type(SERVICE),tag(app) becomes type(SERVICE),tag(app),entityId(SERVICE-123) and entityId(SERVICE-1,SERVICE-2) becomes entityId(SERVICE-2)
+ * Returns false with the unchanged entitySelector if the entitySelector selects another entity type than the one of the entity ID
+ */
+func addEntityIDToEntitySelector(entitySelector string, entityID string) (string, bool) {
+	entityIDCriterion := fmt.Sprintf("entityId(%s)", quoteSelectorValue(entityID))
+	if entitySelector == "" {
+		return entityIDCriterion, true
 	}
-	// for rate measures such as failure rate we take average if it is "OF_INTEREST_RATIO"
-	if metricAggregation == "OF_INTEREST_RATIO" {
-		metricAggregation = "avg"
+
+	criteria := splitSelectorCriteria(entitySelector)
+	entityIDIx := -1
+	for ix, criterion := range criteria {
+		name, value := getSelectorCriterion(criterion)
+		switch name {
+		case "type":
+			if !strings.HasPrefix(entityID, value+"-") {
+				return entitySelector, false
+			}
+		case "entityId":
+			entityIDIx = ix
+		}
 	}
-	// for rate measures charting also provides the "OTHER_RATIO" option which is the inverse
-	// TODO: not supported via API - so we default to avg
-	if metricAggregation == "OTHER_RATIO" {
-		metricAggregation = "avg"
+
+	// an entitySelector can only have one entityId criterion
+	if entityIDIx >= 0 {
+		criteria[entityIDIx] = entityIDCriterion
+	} else {
+		criteria = append(criteria, entityIDCriterion)
 	}
+	return strings.Join(criteria, ","), true
+}
 
-	// TODO - handle aggregation rates -> probably doesnt make sense as we always evalute a short timeframe
-	// if series.AggregationRate
+/**
+ * Splits an entitySelector into its criteria, e.g: type(SERVICE),tag("a,b") becomes type(SERVICE) & tag("a,b")
+ * Commas within parentheses or quotes dont split a criterion. A tilde escapes the next character
+ */
+func splitSelectorCriteria(selector string) []string {
+	var criteria []string
+	depth := 0
+	quoted := false
+	escaped := false
+	start := 0
+	for ix, char := range selector {
+		switch {
+		case escaped:
+			escaped = false
+		case char == '~':
+			escaped = true
+		case char == '"':
+			quoted = !quoted
+		case quoted:
+		case char == '(':
+			depth++
+		case char == ')':
+			depth--
+		case char == ',' && depth == 0:
+			criteria = append(criteria, selector[start:ix])
+			start = ix + 1
+		}
+	}
+	return append(criteria, selector[start:])
+}
 
-	// lets get the true entity type as the one in the dashboard might not be accurate, e.g: IOT might be used instead of CUSTOM_DEVICE
-	// so - if the metric definition has EntityTypes defined we take the first one
-	entityType := series.EntityType
-	if len(metricDefinition.EntityType) > 0 {
-		entityType = metricDefinition.EntityType[0]
+/**
+ * Returns the name and the unquoted value of a selector criterion, e.g: type, SERVICE for type("SERVICE")
+ */
+func getSelectorCriterion(criterion string) (string, string) {
+	criterion = strings.TrimSpace(criterion)
+	openIx := strings.Index(criterion, "(")
+	if openIx < 0 || !strings.HasSuffix(criterion, ")") {
+		return criterion, ""
 	}
+	return criterion[:openIx], strings.Trim(criterion[openIx+1:len(criterion)-1], "\"")
+}
 
-	// Need to implement chart filters per entity type, e.g: its possible that a chart has a filter on entites or tags
-	// lets see if we have a FiltersPerEntityType for the tiles EntityType
-	entityTileFilter := ph.GetEntitySelectorFromEntityFilter(filtersPerEntityType, entityType)
+// metricQueryParameter is a parameter of a metric query, e.g: entitySelector=type(SERVICE). The value is kept as it is in the query
+type metricQueryParameter struct {
+	Name  string
+	Value string
+}
 
-	// lets create the metricSelector and entitySelector
-	// ATTENTION: adding :names so we also get the names of the dimensions and not just the entities. This means we get two values for each dimension
-	metricQuery := fmt.Sprintf("metricSelector=%s%s%s:%s:names&entitySelector=type(%s)%s%s",
-		series.Metric, mergeAggregator, filterAggregator, strings.ToLower(metricAggregation),
-		entityType, entityTileFilter, tileManagementZoneFilter)
+/**
+ * Splits a metric query into its parameters - the order and the values are kept as they are so that the query only changes where we change a parameter
+ */
+func parseMetricQueryParameters(metricQuery string) []metricQueryParameter {
+	var parameters []metricQueryParameter
+	for _, parameter := range strings.Split(metricQuery, "&") {
+		if parameter == "" {
+			continue
+		}
+		nameValue := strings.SplitN(parameter, "=", 2)
+		if len(nameValue) == 1 {
+			nameValue = append(nameValue, "")
+		}
+		parameters = append(parameters, metricQueryParameter{Name: nameValue[0], Value: nameValue[1]})
+	}
+	return parameters
+}
 
-	// lets build the Dynatrace API Metric query for the proposed timeframe and additonal filters!
-	fullMetricQuery, metricID, err := ph.BuildDynatraceMetricsQuery(metricQuery, startUnix, endUnix)
-	if err != nil {
-		return "", "", "", "", "", "", err
+/**
+ * Returns the unescaped value of a metric query parameter - empty if the parameter doesnt exist
+ */
+func getMetricQueryParameter(parameters []metricQueryParameter, name string) string {
+	for _, parameter := range parameters {
+		if parameter.Name == name {
+			value, err := url.QueryUnescape(strings.Replace(parameter.Value, "+", "%2B", -1))
+			if err != nil {
+				return parameter.Value
+			}
+			return value
+		}
 	}
+	return ""
+}
+
+/**
+ * Replaces the value of a metric query parameter or adds the parameter if it doesnt exist yet. The value has to be escaped already
+ */
+func setMetricQueryParameter(parameters []metricQueryParameter, name string, value string) []metricQueryParameter {
+	for ix, parameter := range parameters {
+		if parameter.Name == name {
+			parameters[ix].Value = value
+			return parameters
+		}
+	}
+	return append(parameters, metricQueryParameter{Name: name, Value: value})
+}
 
-	return metricID, metricDefinition.Unit, metricQuery, fullMetricQuery, entitySelectorSLIDefinition, filterSLIDefinitionAggregator, nil
+func encodeMetricQueryParameters(parameters []metricQueryParameter) string {
+	var encodedParameters []string
+	for _, parameter := range parameters {
+		encodedParameters = append(encodedParameters, parameter.Name+"="+parameter.Value)
+	}
+	return strings.Join(encodedParameters, "&")
 }
 
 /**
  * Generates the relvant SLIs & SLO definitions based on the metric query
- * noOfDimensionsInChart: how many dimensions did we have in the chart definition
+ * splitDimensions: the dimensions the chart or data explorer splits by. Every value becomes its own SLI
  */
-func (ph *Handler) GenerateSLISLOFromMetricsAPIQuery(noOfDimensionsInChart int, baseIndicatorName string, passSLOs []*keptncommon.SLOCriteria, warningSLOs []*keptncommon.SLOCriteria, weight int, keySli bool, metricID string, metricUnit string, metricQuery string, fullMetricQuery string, filterSLIDefinitionAggregator string, entitySelectorSLIDefinition string, dashboardSLI *SLI, dashboardSLO *keptncommon.ServiceLevelObjectives) []*keptnv2.SLIResult {
+func (ph *Handler) GenerateSLISLOFromMetricsAPIQuery(splitDimensions []SplitDimension, baseIndicatorName string, passSLOs []*keptncommon.SLOCriteria, warningSLOs []*keptncommon.SLOCriteria, weight int, keySli bool, metricID string, metricUnit string, metricQuery string, fullMetricQuery string, dashboardSLI *SLI, dashboardSLO *keptncommon.ServiceLevelObjectives, startUnix time.Time, endUnix time.Time) []*keptnv2.SLIResult {
 
 	var sliResults []*keptnv2.SLIResult
 
@@ -1491,9 +3031,8 @@ func (ph *Handler) GenerateSLISLOFromMetricsAPIQuery(noOfDimensionsInChart int,
 		for _, singleResult := range queryResult.Result {
 			log.WithFields(
 				log.Fields{
-					"metricId":                      singleResult.MetricID,
-					"filterSLIDefinitionAggregator": filterSLIDefinitionAggregator,
-					"entitySelectorSLIDefinition":   entitySelectorSLIDefinition,
+					"metricId":        singleResult.MetricID,
+					"splitDimensions": splitDimensions,
 				}).Debug("Processing result")
 			if ph.isMatchingMetricID(singleResult.MetricID, metricID) {
 				dataResultCount := len(singleResult.Data)
@@ -1508,31 +3047,12 @@ func (ph *Handler) GenerateSLISLOFromMetricsAPIQuery(noOfDimensionsInChart int,
 
 					metricQueryForSLI := metricQuery
 
-					// we need this one to "fake" the MetricQuery for the SLi.yaml to include the dynamic dimension name for each value
-					// we initialize it with ":names" as this is the part of the metric query string we will replace
-					filterSLIDefinitionAggregatorValue := ":names"
-
 					if dataResultCount > 1 {
-						// because we use the ":names" transformation we always get two dimension entries for entity dimensions, e.g: Host, Service .... First is the Name of the entity, then the ID of the Entity
-						// lets first validate that we really received Dimension Names
-						dimensionCount := len(singleDataEntry.Dimensions)
-						dimensionIncrement := 2
-						if dimensionCount != (noOfDimensionsInChart * 2) {
-							// ph.Logger.Debug(fmt.Sprintf("DIDNT RECEIVE ID and Names. Lets assume we just received the dimension IDs"))
-							dimensionIncrement = 1
-						}
-
-						// lets iterate through the list and get all names
-						for dimIx := 0; dimIx < len(singleDataEntry.Dimensions); dimIx = dimIx + dimensionIncrement {
-							dimensionValue := singleDataEntry.Dimensions[dimIx]
-							indicatorName = indicatorName + "_" + dimensionValue
-
-							filterSLIDefinitionAggregatorValue = ":names" + strings.Replace(filterSLIDefinitionAggregator, "FILTERDIMENSIONVALUE", dimensionValue, 1)
-
-							if entitySelectorSLIDefinition != "" && dimensionIncrement == 2 {
-								dimensionEntityID := singleDataEntry.Dimensions[dimIx+1]
-								metricQueryForSLI = metricQueryForSLI + strings.Replace(entitySelectorSLIDefinition, "FILTERDIMENSIONVALUE", dimensionEntityID, 1)
-							}
+						// every dimension value becomes part of the indicator name & a filter in the SLI definition so that the SLI returns the value for exactly that dimension value
+						var dimensionNames []string
+						dimensionNames, metricQueryForSLI = getSLIDefinitionForSplitDimensions(splitDimensions, singleDataEntry, metricQuery)
+						for _, dimensionName := range dimensionNames {
+							indicatorName = indicatorName + "_" + dimensionName
 						}
 					}
 
@@ -1540,14 +3060,11 @@ func (ph *Handler) GenerateSLISLOFromMetricsAPIQuery(noOfDimensionsInChart int,
 					indicatorName = common.CleanIndicatorName(indicatorName)
 
 					// calculating the value
-					value := 0.0
-					for _, singleValue := range singleDataEntry.Values {
-						value = value + singleValue
-					}
-					value = value / float64(len(singleDataEntry.Values))
+					value := averageValues(singleDataEntry.Values)
 
-					// lets scale the metric
+					// lets scale the metric and normalize it to the rate the chart shows
 					value = scaleData(metricID, metricUnit, value)
+					value = normalizeDataToRate(metricID, metricUnit, value, startUnix, endUnix)
 
 					// we got our metric, slos and the value
 
@@ -1567,7 +3084,7 @@ func (ph *Handler) GenerateSLISLOFromMetricsAPIQuery(noOfDimensionsInChart int,
 					// add this to our SLI Indicator JSON in case we need to generate an SLI.yaml
 					// we use ":names" to find the right spot to add our custom dimension filter
 					// we also "pre-pend" the metricDefinition.Unit - which allows us later on to do the scaling right
-					dashboardSLI.Indicators[indicatorName] = fmt.Sprintf("MV2;%s;%s", metricUnit, strings.Replace(metricQueryForSLI, ":names", filterSLIDefinitionAggregatorValue, 1))
+					dashboardSLI.Indicators[indicatorName] = fmt.Sprintf("MV2;%s;%s", metricUnit, metricQueryForSLI)
 
 					// lets add the SLO definitin in case we need to generate an SLO.yaml
 					sloDefinition := &keptncommon.SLO{
@@ -1589,43 +3106,398 @@ func (ph *Handler) GenerateSLISLOFromMetricsAPIQuery(noOfDimensionsInChart int,
 		}
 	}
 
-	return sliResults
+	return sliResults
+}
+
+/**
+ * Returns a failed SLIResult for a tile that couldnt be evaluated at all, e.g: because of an invalid window=
+ * The SLO definition of the tile title is added as well - otherwise the failure wouldnt show up in the evaluation
+ */
+func addFailedTileSLO(tileName string, err error, dashboardSLO *keptncommon.ServiceLevelObjectives) *keptnv2.SLIResult {
+	baseIndicatorName, passSLOs, warningSLOs, weight, keySli := common.ParsePassAndWarningFromString(tileName, []string{}, []string{})
+	indicatorName := common.CleanIndicatorName(baseIndicatorName)
+
+	dashboardSLO.Objectives = append(dashboardSLO.Objectives, &keptncommon.SLO{
+		SLI:     indicatorName,
+		Weight:  weight,
+		KeySLI:  keySli,
+		Pass:    passSLOs,
+		Warning: warningSLOs,
+	})
+
+	return &keptnv2.SLIResult{
+		Metric:  indicatorName,
+		Value:   0,
+		Success: false,
+		Message: err.Error(),
+	}
+}
+
+/**
+ * getTileWindow
+ * Returns the metric unit options for the window= & reference= values of a tile title, e.g: ,shift=-7d & ,reference=-7d, as well as the timeframe the tile is evaluated on
+ * window=tile evaluates the tile on its own timeframe - or the one of the dashboard - ending with the evaluation, e.g: -2h. window=-7d shifts the evaluation timeframe by 7 days
+ */
+func getTileWindow(tileTitle string, tileTimeframe string, startUnix time.Time, endUnix time.Time) (string, string, time.Time, time.Time, error) {
+	windowOption := ""
+	referenceOption := ""
+	for _, nameValue := range strings.Split(tileTitle, ";") {
+		nameValueDividerIndex := strings.Index(nameValue, "=")
+		if nameValueDividerIndex < 0 {
+			continue
+		}
+		value := nameValue[nameValueDividerIndex+1:]
+
+		switch strings.ToLower(nameValue[:nameValueDividerIndex]) {
+		case "window":
+			if strings.EqualFold(value, "tile") {
+				if tileTimeframe == "" {
+					return "", "", startUnix, endUnix, fmt.Errorf("window=tile requires a timeframe on the tile or the dashboard")
+				}
+				timeframe, _, err := parseRelativeTimeframe(tileTimeframe)
+				if err != nil {
+					return "", "", startUnix, endUnix, err
+				}
+				windowOption = "," + MetricUnitOptionTimeframe + timeframe
+			} else {
+				shift, _, err := parseRelativeTimeframe(value)
+				if err != nil {
+					return "", "", startUnix, endUnix, err
+				}
+				windowOption = "," + MetricUnitOptionShift + shift
+			}
+		case "reference":
+			reference, _, err := parseRelativeTimeframe(value)
+			if err != nil {
+				return "", "", startUnix, endUnix, err
+			}
+			referenceOption = "," + MetricUnitOptionReference + reference
+		}
+	}
+
+	windowStart, windowEnd, err := getMetricUnitTimeframe(windowOption, startUnix, endUnix)
+	return windowOption, referenceOption, windowStart, windowEnd, err
+}
+
+// getRatioCriteria returns the criteria of the ratio SLIs of a tile as specified with ratio.pass= & ratio.warning=, e.g: ratio.pass=<=1.1
+func getRatioCriteria(tileTitle string) ([]*keptncommon.SLOCriteria, []*keptncommon.SLOCriteria) {
+	var ratioValues []string
+	for _, nameValue := range strings.Split(tileTitle, ";") {
+		if strings.HasPrefix(strings.ToLower(nameValue), "ratio.") {
+			ratioValues = append(ratioValues, nameValue[len("ratio."):])
+		}
+	}
+
+	_, passSLOs, warningSLOs, _, _ := common.ParsePassAndWarningFromString(strings.Join(ratioValues, ";"), []string{}, []string{})
+	return passSLOs, warningSLOs
+}
+
+/**
+ * generateRatioSLIs
+ * Generates a <sli>_ratio SLI for every SLI of a tile with reference=, e.g: reference=-7d. Its value is the current value divided by the value of the shifted timeframe
+ * Without ratio.pass= & ratio.warning= the ratio is only informational
+ */
+func (ph *Handler) generateRatioSLIs(sliResults []*keptnv2.SLIResult, referenceOption string, passSLOs []*keptncommon.SLOCriteria, warningSLOs []*keptncommon.SLOCriteria, weight int, dashboardSLI *SLI, dashboardSLO *keptncommon.ServiceLevelObjectives, startUnix time.Time, endUnix time.Time) []*keptnv2.SLIResult {
+	var ratioResults []*keptnv2.SLIResult
+	for _, sliResult := range sliResults {
+		// MV2;unit;query - the reference option is added to the unit
+		querySplits := strings.SplitN(dashboardSLI.Indicators[sliResult.Metric], ";", 3)
+		if !sliResult.Success || len(querySplits) != 3 || querySplits[0] != "MV2" {
+			continue
+		}
+
+		indicatorName := sliResult.Metric + "_ratio"
+		indicatorQuery := fmt.Sprintf("MV2;%s%s;%s", querySplits[1], referenceOption, querySplits[2])
+		dashboardSLI.Indicators[indicatorName] = indicatorQuery
+		dashboardSLO.Objectives = append(dashboardSLO.Objectives, &keptncommon.SLO{
+			SLI:     indicatorName,
+			Weight:  weight,
+			Pass:    passSLOs,
+			Warning: warningSLOs,
+		})
+
+		_, value, err := ph.getMetricsQueryValue(indicatorQuery, startUnix, endUnix)
+		if err != nil {
+			ratioResults = append(ratioResults, &keptnv2.SLIResult{
+				Metric:  indicatorName,
+				Value:   0,
+				Success: false,
+				Message: err.Error(),
+			})
+			continue
+		}
+
+		ratioResults = append(ratioResults, &keptnv2.SLIResult{
+			Metric:  indicatorName,
+			Value:   value,
+			Success: true,
+		})
+	}
+
+	return ratioResults
+}
+
+// QueryDynatraceDashboardForSLIs implements - https://github.com/keptn-contrib/dynatrace-sli-service/issues/60
+// Queries Dynatrace for the existance of a dashboard tagged with keptn_project:project, keptn_stage:stage, keptn_service:service, SLI
+// if this dashboard exists it will be parsed and a custom SLI_dashboard.yaml and an SLO_dashboard.yaml will be created
+// Returns:
+//  #1: Link to Dashboard
+//  #2: SLI
+//  #3: ServiceLevelObjectives
+//  #4: SLIResult
+//  #5: Error
+func (ph *Handler) QueryDynatraceDashboardForSLIs(keptnEvent *common.BaseKeptnEvent, dashboard string, startUnix time.Time, endUnix time.Time) (string, *DynatraceDashboard, *SLI, *keptncommon.ServiceLevelObjectives, []*keptnv2.SLIResult, error) {
+
+	// Lets see if there is a dashboard.json already in the configuration repo - if so its an indicator that we should query the dashboard
+	// This check is espcially important for backward compatibilty as the new dynatrace.conf.yaml:dashboard property is changing the default behavior
+	// If a dashboard.json exists and dashboard property is empty we default to QUERY - which is the old default behavior
+	existingDashboardContent, err := common.GetKeptnResource(keptnEvent, common.DynatraceDashboardFilename)
+	if err == nil && existingDashboardContent != "" && dashboard == "" {
+		log.Debug("Set dashboard=query for backward compatibility as dashboard.json was present!")
+		dashboard = common.DynatraceConfigDashboardQUERY
+	}
+
+	// dashboard: repo - the dashboard.json in the configuration repo is the source of truth - so - we dont load anything from the tenant
+	dashboardFromRepo := dashboard == common.DynatraceConfigDashboardREPO
+
+	// lets load the dashboard if needed
+	var dashboardJSON *DynatraceDashboard
+	if dashboardFromRepo {
+		dashboardJSON, err = parseDynatraceDashboardFromRepo(existingDashboardContent, err)
+	} else {
+		dashboardJSON, dashboard, err = ph.loadDynatraceDashboard(keptnEvent, dashboard)
+	}
+	if err != nil {
+		return "", nil, nil, nil, nil, fmt.Errorf("Error while processing dashboard config '%s' - %v", dashboard, err)
+	}
+
+	if dashboardJSON == nil {
+		return "", nil, nil, nil, nil, nil
+	}
+
+	dashboardLinkAsLabel := ph.getDashboardLink(dashboardJSON, startUnix, endUnix)
+
+	// Lets validate if we really need to process this dashboard as it might be the same (without change) from the previous runs
+	// see https://github.com/keptn-contrib/dynatrace-sli-service/issues/92 for more details
+	// a dashboard from the repo is always compared to itself - so - we always parse it
+	if !dashboardFromRepo && !ph.HasDashboardChanged(keptnEvent, dashboardJSON) {
+		log.Debug("Dashboard hasn't changed: skipping parsing of dashboard")
+		return dashboardLinkAsLabel, nil, nil, nil, nil, nil
+	}
+
+	log.Debug("Dashboard has changed: reparsing it!")
+
+	dashboardSLI, dashboardSLO, sliResults := ph.processDashboardTiles(dashboardJSON, startUnix, endUnix)
+
+	return dashboardLinkAsLabel, dashboardJSON, dashboardSLI, dashboardSLO, sliResults, nil
+}
+
+/**
+ * QueryDynatraceDashboardsForSLIs
+ * Loads & parses all passed dashboards and merges their SLIs, SLOs and SLIResults. The prefix of a dashboard is put in front of all its indicator names
+ * Dashboards are always parsed - change detection only applies to a single dashboard
+ * The comparison settings are taken from the first dashboard. KQG.Total.Pass & KQG.Total.Warning can be set on any dashboard
+ *
+ * Returns an error if a dashboard couldnt be loaded, indicator names are not unique or dashboards specify different KQG.Total.* values
+ * Returns:
+ *  #1: Links to the dashboards
+ *  #2: SLI
+ *  #3: ServiceLevelObjectives
+ *  #4: SLIResult
+ *  #5: Error
+ */
+func (ph *Handler) QueryDynatraceDashboardsForSLIs(keptnEvent *common.BaseKeptnEvent, dashboards []common.DynatraceConfigDashboard, startUnix time.Time, endUnix time.Time) ([]string, *SLI, *keptncommon.ServiceLevelObjectives, []*keptnv2.SLIResult, error) {
+	var dashboardLinks []string
+	var sliResults []*keptnv2.SLIResult
+	mergedSLI := &SLI{
+		SpecVersion: "0.1.4",
+		Indicators:  make(map[string]string),
+	}
+	mergedSLO := &keptncommon.ServiceLevelObjectives{
+		Objectives: []*keptncommon.SLO{},
+		TotalScore: &keptncommon.SLOScore{Pass: "90%", Warning: "75%"},
+	}
+
+	// lets remember which dashboard defined KQG.Total.Pass & KQG.Total.Warning so we can report conflicts
+	totalPassDashboard := ""
+	totalWarningDashboard := ""
+
+	var mergeErrors []string
+	for _, dashboardConfig := range dashboards {
+		dashboardJSON, err := ph.loadDynatraceDashboardForMerge(keptnEvent, dashboardConfig.Dashboard)
+		if err != nil {
+			mergeErrors = append(mergeErrors, fmt.Sprintf("dashboard '%s': %v", dashboardConfig.Dashboard, err))
+			continue
+		}
+
+		if dashboardLink := ph.getDashboardLink(dashboardJSON, startUnix, endUnix); dashboardLink != "" {
+			dashboardLinks = append(dashboardLinks, dashboardLink)
+		}
+
+		dashboardSLI, dashboardSLO, dashboardSLIResults := ph.processDashboardTiles(dashboardJSON, startUnix, endUnix)
+
+		for indicatorName, indicatorQuery := range dashboardSLI.Indicators {
+			indicatorName = dashboardConfig.Prefix + indicatorName
+			if _, exists := mergedSLI.Indicators[indicatorName]; exists {
+				mergeErrors = append(mergeErrors, fmt.Sprintf("dashboard '%s': indicator %s is defined by multiple dashboards", dashboardConfig.Dashboard, indicatorName))
+				continue
+			}
+			mergedSLI.Indicators[indicatorName] = indicatorQuery
+		}
+		for _, objective := range dashboardSLO.Objectives {
+			objective.SLI = dashboardConfig.Prefix + objective.SLI
+			mergedSLO.Objectives = append(mergedSLO.Objectives, objective)
+		}
+		for _, sliResult := range dashboardSLIResults {
+			sliResult.Metric = dashboardConfig.Prefix + sliResult.Metric
+			sliResults = append(sliResults, sliResult)
+		}
+
+		if mergedSLO.Comparison == nil {
+			mergedSLO.Comparison = dashboardSLO.Comparison
+		}
+
+		totalScore := getDashboardTotalScore(dashboardJSON)
+		if totalScore.Pass != "" {
+			if totalPassDashboard != "" && totalScore.Pass != mergedSLO.TotalScore.Pass {
+				mergeErrors = append(mergeErrors, fmt.Sprintf("dashboard '%s': KQG.Total.Pass=%s conflicts with KQG.Total.Pass=%s of dashboard '%s'", dashboardConfig.Dashboard, totalScore.Pass, mergedSLO.TotalScore.Pass, totalPassDashboard))
+			} else {
+				mergedSLO.TotalScore.Pass = totalScore.Pass
+				totalPassDashboard = dashboardConfig.Dashboard
+			}
+		}
+		if totalScore.Warning != "" {
+			if totalWarningDashboard != "" && totalScore.Warning != mergedSLO.TotalScore.Warning {
+				mergeErrors = append(mergeErrors, fmt.Sprintf("dashboard '%s': KQG.Total.Warning=%s conflicts with KQG.Total.Warning=%s of dashboard '%s'", dashboardConfig.Dashboard, totalScore.Warning, mergedSLO.TotalScore.Warning, totalWarningDashboard))
+			} else {
+				mergedSLO.TotalScore.Warning = totalScore.Warning
+				totalWarningDashboard = dashboardConfig.Dashboard
+			}
+		}
+	}
+
+	if len(mergeErrors) > 0 {
+		return dashboardLinks, nil, nil, nil, fmt.Errorf("Couldnt merge dashboards: %s", strings.Join(mergeErrors, "; "))
+	}
+
+	return dashboardLinks, mergedSLI, mergedSLO, sliResults, nil
+}
+
+/**
+ * loadDynatraceDashboardForMerge
+ * Loads a dashboard that is part of dynatrace.conf.yaml:dashboards. Other than for a single dashboard it is an error if no dashboard is found
+ */
+func (ph *Handler) loadDynatraceDashboardForMerge(keptnEvent *common.BaseKeptnEvent, dashboard string) (*DynatraceDashboard, error) {
+	if dashboard == common.DynatraceConfigDashboardREPO {
+		dashboardContent, err := common.GetKeptnResource(keptnEvent, common.DynatraceDashboardFilename)
+		return parseDynatraceDashboardFromRepo(dashboardContent, err)
+	}
+
+	dashboardJSON, _, err := ph.loadDynatraceDashboard(keptnEvent, dashboard)
+	if err != nil {
+		return nil, err
+	}
+	if dashboardJSON == nil {
+		return nil, fmt.Errorf("No dashboard found")
+	}
+
+	return dashboardJSON, nil
+}
+
+/**
+ * getDashboardTotalScore
+ * Returns KQG.Total.Pass & KQG.Total.Warning as explicitly set in the markdown tiles or tags of the dashboard - values that are not set are returned as ""
+ */
+func getDashboardTotalScore(dashboardJSON *DynatraceDashboard) *keptncommon.SLOScore {
+	markdownConfig, _ := getDashboardConfiguration(dashboardJSON)
+
+	return &keptncommon.SLOScore{Pass: markdownConfig.TotalPass, Warning: markdownConfig.TotalWarning}
+}
+
+/**
+ * getDashboardConfiguration
+ * Parses the KQG settings of the dashboard tags, e.g: kqg.total.pass:90%, and of all markdown tiles. Returns the settings and the problems found in them including their position
+ * Markdown settings win over tags - except for KQG.Exclude which adds up
+ */
+func getDashboardConfiguration(dashboardJSON *DynatraceDashboard) (*common.MarkdownConfiguration, []string) {
+	markdownConfig := &common.MarkdownConfiguration{}
+	var problems []string
+	for _, err := range markdownConfig.ParseTags(dashboardJSON.DashboardMetadata.Tags) {
+		problems = append(problems, fmt.Sprintf("dashboard '%s' %v", dashboardJSON.DashboardMetadata.Name, err))
+	}
+	for tileIndex, tile := range dashboardJSON.Tiles {
+		if tile.TileType != "MARKDOWN" {
+			continue
+		}
+		for _, err := range markdownConfig.Parse(tile.Markdown) {
+			problems = append(problems, fmt.Sprintf("dashboard '%s' markdown tile %d %v", dashboardJSON.DashboardMetadata.Name, tileIndex+1, err))
+		}
+	}
+
+	return markdownConfig, problems
 }
 
-// QueryDynatraceDashboardForSLIs implements - https://github.com/keptn-contrib/dynatrace-sli-service/issues/60
-// Queries Dynatrace for the existance of a dashboard tagged with keptn_project:project, keptn_stage:stage, keptn_service:service, SLI
-// if this dashboard exists it will be parsed and a custom SLI_dashboard.yaml and an SLO_dashboard.yaml will be created
-// Returns:
-//  #1: Link to Dashboard
-//  #2: SLI
-//  #3: ServiceLevelObjectives
-//  #4: SLIResult
-//  #5: Error
-func (ph *Handler) QueryDynatraceDashboardForSLIs(keptnEvent *common.BaseKeptnEvent, dashboard string, startUnix time.Time, endUnix time.Time) (string, *DynatraceDashboard, *SLI, *keptncommon.ServiceLevelObjectives, []*keptnv2.SLIResult, error) {
+/**
+ * getDashboardLink
+ * Generates the dashboard link for that timeframe (gtf=c_START_END) as well as management zone (gf=MZID) to pass back as label to Keptn
+ * A dashboard from the repo doesnt need to have an ID - in that case there is nothing we can link to and we return ""
+ */
+func (ph *Handler) getDashboardLink(dashboardJSON *DynatraceDashboard, startUnix time.Time, endUnix time.Time) string {
+	if dashboardJSON.ID == "" {
+		return ""
+	}
 
-	// Lets see if there is a dashboard.json already in the configuration repo - if so its an indicator that we should query the dashboard
-	// This check is espcially important for backward compatibilty as the new dynatrace.conf.yaml:dashboard property is changing the default behavior
-	// If a dashboard.json exists and dashboard property is empty we default to QUERY - which is the old default behavior
-	existingDashboardContent, err := common.GetKeptnResource(keptnEvent, common.DynatraceDashboardFilename)
-	if err == nil && existingDashboardContent != "" && dashboard == "" {
-		log.Debug("Set dashboard=query for backward compatibility as dashboard.json was present!")
-		dashboard = common.DynatraceConfigDashboardQUERY
+	// convert timestamp to string as we mainly need strings later on
+	startInString := common.TimestampToString(startUnix)
+	endInString := common.TimestampToString(endUnix)
+
+	mgmtZone := ""
+	if dashboardJSON.DashboardMetadata.DashboardFilter != nil && dashboardJSON.DashboardMetadata.DashboardFilter.ManagementZone != nil {
+		mgmtZone = ";gf=" + dashboardJSON.DashboardMetadata.DashboardFilter.ManagementZone.ID
 	}
 
-	// lets load the dashboard if needed
-	dashboardJSON, dashboard, err := ph.loadDynatraceDashboard(keptnEvent, dashboard)
-	if err != nil {
-		return "", nil, nil, nil, nil, fmt.Errorf("Error while processing dashboard config '%s' - %v", dashboard, err)
+	return fmt.Sprintf("%s#dashboard;id=%s;gtf=c_%s_%s%s", ph.ApiURL, dashboardJSON.ID, startInString, endInString, mgmtZone)
+}
+
+// dashboardSection is a HEADER tile that defines defaults for the tiles placed below it
+type dashboardSection struct {
+	top, left, width int
+	defaults         string
+}
+
+/**
+ * Returns the defaults of the section a tile is placed in - or "" if it isnt in a section
+ * A tile belongs to the closest section header above it that horizontally overlaps with the tile
+ */
+func getDashboardSectionDefaults(sections []dashboardSection, top int, left int, width int) string {
+	var closestSection *dashboardSection
+	for ix := range sections {
+		section := &sections[ix]
+		if section.top > top || section.left >= left+width || section.left+section.width <= left {
+			continue
+		}
+		if closestSection == nil || section.top > closestSection.top {
+			closestSection = section
+		}
 	}
 
-	if dashboardJSON == nil {
-		return "", nil, nil, nil, nil, nil
+	if closestSection == nil {
+		return ""
 	}
+	return closestSection.defaults
+}
+
+/**
+ * processDashboardTiles
+ * Iterates through all tiles of the dashboard and generates SLI definitions, SLO definitions and SLIResults for those tiles that should be part of the evaluation
+ */
+func (ph *Handler) processDashboardTiles(dashboardJSON *DynatraceDashboard, startUnix time.Time, endUnix time.Time) (*SLI, *keptncommon.ServiceLevelObjectives, []*keptnv2.SLIResult) {
 
 	// generate our own SLIResult array based on the dashboard configuration
 	var sliResults []*keptnv2.SLIResult
 	dashboardSLI := &SLI{}
 	dashboardSLI.SpecVersion = "0.1.4"
+	dashboardSLI.DashboardVersion = getDashboardVersion(dashboardJSON)
 	dashboardSLI.Indicators = make(map[string]string)
 	dashboardSLO := &keptncommon.ServiceLevelObjectives{
 		Objectives: []*keptncommon.SLO{},
@@ -1633,50 +3505,71 @@ func (ph *Handler) QueryDynatraceDashboardForSLIs(keptnEvent *common.BaseKeptnEv
 		Comparison: &keptncommon.SLOComparison{CompareWith: "single_result", IncludeResultWithScore: "pass", NumberOfComparisonResults: 1, AggregateFunction: "avg"},
 	}
 
-	// convert timestamp to string as we mainly need strings later on
-	startInString := common.TimestampToString(startUnix)
-	endInString := common.TimestampToString(endUnix)
-
-	// if there is a dashboard management zone filter get them for both the queries as well as for the dashboard link
+	// if there is a dashboard management zone filter get them for the queries
 	dashboardManagementZoneFilter := ""
-	mgmtZone := ""
 	if dashboardJSON.DashboardMetadata.DashboardFilter != nil && dashboardJSON.DashboardMetadata.DashboardFilter.ManagementZone != nil {
 		dashboardManagementZoneFilter = fmt.Sprintf(",mzId(%s)", dashboardJSON.DashboardMetadata.DashboardFilter.ManagementZone.ID)
-		mgmtZone = ";gf=" + dashboardJSON.DashboardMetadata.DashboardFilter.ManagementZone.ID
 	}
 
-	// lets also generate the dashboard link for that timeframe (gtf=c_START_END) as well as management zone (gf=MZID) to pass back as label to Keptn
-	dashboardLinkAsLabel := fmt.Sprintf("%s#dashboard;id=%s;gtf=c_%s_%s%s", ph.ApiURL, dashboardJSON.ID, startInString, endInString, mgmtZone)
-
-	// Lets validate if we really need to process this dashboard as it might be the same (without change) from the previous runs
-	// see https://github.com/keptn-contrib/dynatrace-sli-service/issues/92 for more details
-	if !ph.HasDashboardChanged(keptnEvent, dashboardJSON, existingDashboardContent) {
-		log.Debug("Dashboard hasn't changed: skipping parsing of dashboard")
-		return dashboardLinkAsLabel, nil, nil, nil, nil, nil
+	// markdown tiles & tags define settings for the whole dashboard - so - we parse them first as they apply to all tiles no matter where they are placed
+	markdownConfig, markdownProblems := getDashboardConfiguration(dashboardJSON)
+	markdownConfig.ApplyToSLO(dashboardSLO)
+	for _, problem := range markdownProblems {
+		log.Warn(problem)
 	}
+	ph.DashboardProblems = append(ph.DashboardProblems, markdownProblems...)
 
-	log.Debug("Dashboard has changed: reparsing it!")
-
-	//
-	// now lets iterate through the dashboard to find our SLIs
+	// HEADER tiles can define defaults for the tiles of their section, e.g: KQG.Section;prefix=perf_;weight=2
+	var sections []dashboardSection
 	for _, tile := range dashboardJSON.Tiles {
-		if tile.TileType == "HEADER" {
-			// we dont do markdowns or synthetic tests
+		if tile.TileType != "HEADER" {
 			continue
 		}
+		if sectionDefaults, isSection := common.ParseSectionHeader(tile.Name); isSection {
+			sections = append(sections, dashboardSection{top: tile.Bounds.Top, left: tile.Bounds.Left, width: tile.Bounds.Width, defaults: sectionDefaults})
+		}
+	}
+
+	// the parse report tells the dashboard author which tiles became SLIs - we add the SLIResults of a tile to it once we move on to the next one
+	parseReport := &DashboardParseReport{DashboardID: dashboardJSON.ID, DashboardName: dashboardJSON.DashboardMetadata.Name}
+	ph.DashboardParseReports = append(ph.DashboardParseReports, parseReport)
+	ph.DashboardReparsed = true
+	var tileReport *TileParseReport
+	reportedSLIResults := 0
+	finishTileReport := func() {
+		if tileReport != nil {
+			tileReport.addSLIResults(sliResults[reportedSLIResults:], dashboardSLI)
+		}
+		reportedSLIResults = len(sliResults)
+	}
 
-		if tile.TileType == "SYNTHETIC_TESTS" {
-			// we dont do markdowns or synthetic tests
+	//
+	// now lets iterate through the dashboard to find our SLIs
+	for tileIndex, tile := range dashboardJSON.Tiles {
+		finishTileReport()
+		tileReport = &TileParseReport{Index: tileIndex + 1, TileType: tile.TileType, Title: tile.Name, Decision: TileDecisionSkipped}
+		parseReport.Tiles = append(parseReport.Tiles, tileReport)
+
+		if tile.TileType == "HEADER" || tile.TileType == "MARKDOWN" {
+			// headers only define sections & markdowns have already been parsed
+			tileReport.Reason = "header and markdown tiles only hold settings"
 			continue
 		}
 
-		if tile.TileType == "MARKDOWN" {
-			// we allow the user to use a markdown to specify SLI/SLO properties, e.g: KQG.Total.Pass
-			// if we find KQG. we process the markdown
-			if strings.Contains(tile.Markdown, "KQG.") {
-				common.ParseMarkdownConfiguration(tile.Markdown, dashboardSLO)
-			}
+		sectionDefaults := getDashboardSectionDefaults(sections, tile.Bounds.Top, tile.Bounds.Left, tile.Bounds.Width)
 
+		// custom chart and usql have different ways to define their tile names - so - lets figure it out by looking at the potential values
+		tileTitle := tile.FilterConfig.CustomName // this is for all custom charts
+		if tileTitle == "" {
+			tileTitle = tile.CustomName
+		}
+		if tileTitle == "" {
+			tileTitle = tile.Name
+		}
+		tileReport.Title = tileTitle
+		if markdownConfig.IsExcluded(tileTitle) {
+			log.WithField("tileTitle", tileTitle).Debug("Tile not included as it is listed in KQG.Exclude")
+			tileReport.Reason = "listed in KQG.Exclude"
 			continue
 		}
 
@@ -1687,6 +3580,12 @@ func (ph *Handler) QueryDynatraceDashboardForSLIs(keptnEvent *common.BaseKeptnEv
 			tileManagementZoneFilter = fmt.Sprintf(",mzId(%s)", tile.TileFilter.ManagementZone.ID)
 		}
 
+		// the timeframe of the tile is only used with window=tile - it overwrites the dashboard timeframe
+		tileTimeframe := tile.TileFilter.Timeframe
+		if tileTimeframe == "" && dashboardJSON.DashboardMetadata.DashboardFilter != nil {
+			tileTimeframe = dashboardJSON.DashboardMetadata.DashboardFilter.Timeframe
+		}
+
 		if tile.TileType == "SLO" {
 			// we will take the SLO definition from Dynatrace
 			for _, sloEntity := range tile.AssignedEntities {
@@ -1695,6 +3594,7 @@ func (ph *Handler) QueryDynatraceDashboardForSLIs(keptnEvent *common.BaseKeptnEv
 				sliResult, sliIndicator, sliQuery, sloDefinition, err := ph.ProcessSLOTile(sloEntity, startUnix, endUnix)
 				if err != nil {
 					log.WithError(err).Error("Error Processing SLO")
+					tileReport.addFailure(err.Error())
 				} else {
 					sliResults = append(sliResults, sliResult)
 					dashboardSLI.Indicators[sliIndicator] = sliQuery
@@ -1719,6 +3619,7 @@ func (ph *Handler) QueryDynatraceDashboardForSLIs(keptnEvent *common.BaseKeptnEv
 			sliResult, sliIndicator, sliQuery, sloDefinition, err := ph.ProcessOpenProblemTile(problemSelector, entitySelector, startUnix, endUnix)
 			if err != nil {
 				log.WithError(err).Error("Error Processing OPEN_PROBLEMS")
+				tileReport.addFailure(err.Error())
 			} else {
 				sliResults = append(sliResults, sliResult)
 				dashboardSLI.Indicators[sliIndicator] = sliQuery
@@ -1740,6 +3641,7 @@ func (ph *Handler) QueryDynatraceDashboardForSLIs(keptnEvent *common.BaseKeptnEv
 			sliResult, sliIndicator, sliQuery, sloDefinition, err := ph.ProcessOpenSecurityProblemTile(problemSelector, startUnix, endUnix)
 			if err != nil {
 				log.WithError(err).Error("Error Processing OPEN_SECURITY_PROBLEMS")
+				tileReport.addFailure(err.Error())
 			} else {
 				sliResults = append(sliResults, sliResult)
 				dashboardSLI.Indicators[sliIndicator] = sliQuery
@@ -1752,23 +3654,63 @@ func (ph *Handler) QueryDynatraceDashboardForSLIs(keptnEvent *common.BaseKeptnEv
 		if tile.TileType == "DATA_EXPLORER" {
 
 			// first - lets figure out if this tile should be included in SLI validation or not - we parse the title and look for "sli=sliname"
-			baseIndicatorName, passSLOs, warningSLOs, weight, keySli := common.ParsePassAndWarningFromString(tile.Name, []string{}, []string{})
+			baseIndicatorName, titlePassSLOs, titleWarningSLOs, _, _ := common.ParsePassAndWarningFromString(tile.Name, []string{}, []string{})
 			if baseIndicatorName == "" {
 				log.WithField("tileName", tile.Name).Debug("Data explorer tile not included as name doesnt include sli=SLINAME")
+				tileReport.Reason = "title doesnt include sli=SLINAME"
+				continue
+			}
+
+			// window= & reference= can move the timeframe of the tile or compare it with a reference timeframe
+			tileName := markdownConfig.ApplyTileDefaults(common.ApplySectionDefaults(tile.Name, sectionDefaults))
+			windowOption, referenceOption, windowStart, windowEnd, err := getTileWindow(tileName, tileTimeframe, startUnix, endUnix)
+			if err != nil {
+				log.WithError(err).WithField("tileName", tileName).Error("Couldnt get timeframe of data explorer tile")
+				sliResults = append(sliResults, addFailedTileSLO(tileName, err, dashboardSLO))
 				continue
 			}
+			ratioPassSLOs, ratioWarningSLOs := getRatioCriteria(tileName)
+
+			resolution := ""
+			if tile.QueriesSettings != nil {
+				resolution = tile.QueriesSettings.Resolution
+			}
 
 			// now lets process that tile - lets run through each query
 			for _, dataQuery := range tile.Queries {
 				log.WithField("metric", dataQuery.Metric).Debug("Processing data explorer query")
 
+				// disabled queries are not shown on the tile
+				if dataQuery.Enabled != nil && !*dataQuery.Enabled {
+					continue
+				}
+
+				// criteria in the title win - otherwise the thresholds of the tile are used before falling back to section & dashboard defaults
+				tileName := tile.Name
+				if titlePassSLOs == nil && titleWarningSLOs == nil {
+					thresholdPass, thresholdWarning := getThresholdCriteria(tile.VisualConfig, dataQuery.ID)
+					if thresholdPass != nil {
+						tileName = tileName + ";pass=" + strings.Join(thresholdPass, ",")
+					}
+					if thresholdWarning != nil {
+						tileName = tileName + ";warning=" + strings.Join(thresholdWarning, ",")
+					}
+				}
+				baseIndicatorName, passSLOs, warningSLOs, weight, keySli := common.ParsePassAndWarningFromString(markdownConfig.ApplyTileDefaults(common.ApplySectionDefaults(tileName, sectionDefaults)), []string{}, []string{})
+
 				// First lets generate the query and extract all important metric information we need for generating SLIs & SLOs
-				metricID, metricUnit, metricQuery, fullMetricQuery, entitySelectorSLIDefinition, filterSLIDefinitionAggregator, err := ph.GenerateMetricQueryFromDataExplorer(dataQuery, tileManagementZoneFilter, startUnix, endUnix)
+				metricID, metricUnit, metricQuery, fullMetricQuery, splitDimensions, err := ph.GenerateMetricQueryFromDataExplorer(dataQuery, tile.Queries, resolution, tileManagementZoneFilter, windowStart, windowEnd)
 
 				// if there was no error we generate the SLO & SLO definition
 				if err == nil {
-					newSliResults := ph.GenerateSLISLOFromMetricsAPIQuery(len(dataQuery.SplitBy), baseIndicatorName, passSLOs, warningSLOs, weight, keySli, metricID, metricUnit, metricQuery, fullMetricQuery, filterSLIDefinitionAggregator, entitySelectorSLIDefinition, dashboardSLI, dashboardSLO)
+					newSliResults := ph.GenerateSLISLOFromMetricsAPIQuery(splitDimensions, baseIndicatorName, passSLOs, warningSLOs, weight, keySli, metricID, metricUnit+windowOption, metricQuery, fullMetricQuery, dashboardSLI, dashboardSLO, windowStart, windowEnd)
 					sliResults = append(sliResults, newSliResults...)
+					if referenceOption != "" {
+						sliResults = append(sliResults, ph.generateRatioSLIs(newSliResults, referenceOption, ratioPassSLOs, ratioWarningSLOs, weight, dashboardSLI, dashboardSLO, startUnix, endUnix)...)
+					}
+				} else {
+					log.WithError(err).WithField("tileName", tile.Name).Error("Couldnt generate metric query for data explorer query")
+					tileReport.addFailure(err.Error())
 				}
 
 			}
@@ -1776,19 +3718,30 @@ func (ph *Handler) QueryDynatraceDashboardForSLIs(keptnEvent *common.BaseKeptnEv
 
 		}
 
-		// custom chart and usql have different ways to define their tile names - so - lets figure it out by looking at the potential values
-		tileTitle := tile.FilterConfig.CustomName // this is for all custom charts
-		if tileTitle == "" {
-			tileTitle = tile.CustomName
-		}
-		if tileTitle == "" {
-			tileTitle = tile.Name
-		}
+		tileTitle = markdownConfig.ApplyTileDefaults(common.ApplySectionDefaults(tileTitle, sectionDefaults))
 
 		// first - lets figure out if this tile should be included in SLI validation or not - we parse the title and look for "sli=sliname"
 		baseIndicatorName, passSLOs, warningSLOs, weight, keySli := common.ParsePassAndWarningFromString(tileTitle, []string{}, []string{})
 		if baseIndicatorName == "" {
 			log.WithField("tileTitle", tileTitle).Debug("Tile not included as name doesnt include sli=SLINAME")
+			tileReport.Reason = "title doesnt include sli=SLINAME"
+			continue
+		}
+
+		// entity health tiles are mapped to a defined set of metrics for the entities they show
+		if _, isEntityHealthTile := entityHealthTiles[tile.TileType]; isEntityHealthTile {
+			sliResults = append(sliResults, ph.ProcessEntityHealthTile(tile.TileType, tileTitle, tile.AssignedEntities, tileManagementZoneFilter, dashboardSLI, dashboardSLO, startUnix, endUnix)...)
+			continue
+		}
+
+		// every synthetic monitor assigned to the tile becomes an availability & duration SLI. We dont pick up all monitors of the tenant or management zone for tiles without assigned monitors
+		if tile.TileType == "SYNTHETIC_TESTS" {
+			if len(tile.AssignedEntities) == 0 {
+				log.WithField("tileTitle", tileTitle).Debug("Synthetic tile not included as no monitors are assigned to it")
+				tileReport.Reason = "no synthetic monitors are assigned to the tile"
+				continue
+			}
+			sliResults = append(sliResults, ph.ProcessSyntheticTestsTile(tileTitle, tile.AssignedEntities, dashboardSLI, dashboardSLO, startUnix, endUnix)...)
 			continue
 		}
 
@@ -1800,16 +3753,37 @@ func (ph *Handler) QueryDynatraceDashboardForSLIs(keptnEvent *common.BaseKeptnEv
 					"baseIndicatorName": baseIndicatorName,
 				}).Debug("Processing custom chart")
 
+			// window= & reference= can move the timeframe of the tile or compare it with a reference timeframe
+			windowOption, referenceOption, windowStart, windowEnd, err := getTileWindow(tileTitle, tileTimeframe, startUnix, endUnix)
+			if err != nil {
+				log.WithError(err).WithField("tileTitle", tileTitle).Error("Couldnt get timeframe of custom chart")
+				sliResults = append(sliResults, addFailedTileSLO(tileTitle, err, dashboardSLO))
+				continue
+			}
+			ratioPassSLOs, ratioWarningSLOs := getRatioCriteria(tileTitle)
+
 			// we can potentially have multiple series on that chart
 			for _, series := range tile.FilterConfig.ChartConfig.Series {
 
 				// First lets generate the query and extract all important metric information we need for generating SLIs & SLOs
-				metricID, metricUnit, metricQuery, fullMetricQuery, entitySelectorSLIDefinition, filterSLIDefinitionAggregator, err := ph.GenerateMetricQueryFromChart(series, tileManagementZoneFilter, tile.FilterConfig.FiltersPerEntityType, startUnix, endUnix)
+				metricID, metricUnit, metricQuery, fullMetricQuery, splitDimensions, err := ph.GenerateMetricQueryFromChart(series, tileManagementZoneFilter, tile.FilterConfig.FiltersPerEntityType, windowStart, windowEnd)
 
 				// if there was no error we generate the SLO & SLO definition
 				if err == nil {
-					newSliResults := ph.GenerateSLISLOFromMetricsAPIQuery(len(series.Dimensions), baseIndicatorName, passSLOs, warningSLOs, weight, keySli, metricID, metricUnit, metricQuery, fullMetricQuery, filterSLIDefinitionAggregator, entitySelectorSLIDefinition, dashboardSLI, dashboardSLO)
+					newSliResults := ph.GenerateSLISLOFromMetricsAPIQuery(splitDimensions, baseIndicatorName, passSLOs, warningSLOs, weight, keySli, metricID, metricUnit+windowOption, metricQuery, fullMetricQuery, dashboardSLI, dashboardSLO, windowStart, windowEnd)
 					sliResults = append(sliResults, newSliResults...)
+					if referenceOption != "" {
+						sliResults = append(sliResults, ph.generateRatioSLIs(newSliResults, referenceOption, ratioPassSLOs, ratioWarningSLOs, weight, dashboardSLI, dashboardSLO, startUnix, endUnix)...)
+					}
+				} else {
+					// we dont want to silently evaluate something different than what the chart shows - so - we report the error as part of our SLIResults
+					log.WithError(err).WithField("tileTitle", tileTitle).Error("Couldnt generate metric query for chart series")
+					sliResults = append(sliResults, &keptnv2.SLIResult{
+						Metric:  baseIndicatorName,
+						Value:   0,
+						Success: false,
+						Message: err.Error(),
+					})
 				}
 			}
 		}
@@ -1821,75 +3795,128 @@ func (ph *Handler) QueryDynatraceDashboardForSLIs(keptnEvent *common.BaseKeptnEv
 			// SINGLE_VALUE: we just take the one value that comes back
 			// PIE_CHART, COLUMN_CHART: we assume the first column is the dimension and the second column is the value column
 			// TABLE: we assume the first column is the dimension and the last is the value
+			// LINE_CHART: we assume the first column is the time, the second the dimension (if there are more than 2 columns) and the last is the value which we average
+			// the columns can also be picked by name or index in the tile title, e.g: sli=city_duration;dimension=city;value=avg(duration)
+			if tile.Type != USQLTileTypeSingleValue && tile.Type != USQLTileTypePieChart && tile.Type != USQLTileTypeColumnChart && tile.Type != USQLTileTypeTable && tile.Type != USQLTileTypeLineChart {
+				log.WithField("tileType", tile.Type).Debug("Unsupport USQL tile type")
+				tileReport.Reason = fmt.Sprintf("USQL tile type %s is not supported", tile.Type)
+				continue
+			}
+			dimensionColumn, valueColumns := getUSQLColumnsFromTileTitle(tileTitle)
 
 			usql := ph.BuildDynatraceUSQLQuery(tile.Query, startUnix, endUnix)
 			usqlResult, err := ph.ExecuteUSQLQuery(usql)
 
-			if err != nil {
-
-			} else {
+			var usqlValues []*USQLValue
+			if err == nil {
+				usqlValues, err = DecodeUSQLResult(usqlResult, tile.Type, dimensionColumn, valueColumns)
+			}
 
-				for _, rowValue := range usqlResult.Values {
-					dimensionName := ""
-					dimensionValue := 0.0
-
-					if tile.Type == "SINGLE_VALUE" {
-						dimensionValue = rowValue[0].(float64)
-					} else if tile.Type == "PIE_CHART" {
-						dimensionName = rowValue[0].(string)
-						dimensionValue = rowValue[1].(float64)
-					} else if tile.Type == "COLUMN_CHART" {
-						dimensionName = rowValue[0].(string)
-						dimensionValue = rowValue[1].(float64)
-					} else if tile.Type == "TABLE" {
-						dimensionName = rowValue[0].(string)
-						dimensionValue = rowValue[len(rowValue)-1].(float64)
-					} else {
-						log.WithField("tileType", tile.Type).Debug("Unsupport USQL tile type")
-						continue
-					}
+			if err != nil {
+				log.WithError(err).WithField("tileTitle", tileTitle).Error("Couldnt execute USQL query")
+				tileReport.addFailure(err.Error())
+				continue
+			}
 
-					// lets scale the metric
-					// value = scaleData(metricDefinition.MetricID, metricDefinition.Unit, value)
+			// if there is more than one value column each column becomes its own SLI
+			multipleValueColumns := false
+			for _, usqlValue := range usqlValues {
+				if usqlValue.ColumnIndex != usqlValues[0].ColumnIndex {
+					multipleValueColumns = true
+				}
+			}
 
-					// we got our metric, slos and the value
-					indicatorName := baseIndicatorName
-					if dimensionName != "" {
-						indicatorName = indicatorName + "_" + dimensionName
-					}
+			for _, usqlValue := range usqlValues {
+				// we got our metric, slos and the value
+				indicatorName := baseIndicatorName
+				if usqlValue.Dimension != "" {
+					indicatorName = indicatorName + "_" + usqlValue.Dimension
+				}
+				if multipleValueColumns {
+					indicatorName = indicatorName + "_" + usqlValue.Column
+				}
 
-					log.WithFields(
-						log.Fields{
-							"name":           indicatorName,
-							"dimensionValue": dimensionValue,
-						}).Debug("Appending SLIResult")
+				log.WithFields(
+					log.Fields{
+						"name":           indicatorName,
+						"dimensionValue": usqlValue.Value,
+					}).Debug("Appending SLIResult")
 
-					// lets add the value to our SLIResult array
+				// lets add the value to our SLIResult array - a cell that couldnt be decoded, e.g: null, fails this SLI
+				if usqlValue.Err != nil {
+					sliResults = append(sliResults, &keptnv2.SLIResult{
+						Metric:  indicatorName,
+						Value:   0,
+						Success: false,
+						Message: usqlValue.Err.Error(),
+					})
+				} else {
 					sliResults = append(sliResults, &keptnv2.SLIResult{
 						Metric:  indicatorName,
-						Value:   dimensionValue,
+						Value:   usqlValue.Value,
 						Success: true,
 					})
+				}
 
-					// add this to our SLI Indicator JSON in case we need to generate an SLI.yaml
-					// in that case we also need to mask it with USQL, TITLE_TYPE, DIMENSIONNAME
-					dashboardSLI.Indicators[indicatorName] = fmt.Sprintf("USQL;%s;%s;%s", tile.Type, dimensionName, tile.Query)
-
-					// lets add the SLO definitin in case we need to generate an SLO.yaml
-					sloDefinition := &keptncommon.SLO{
-						SLI:     indicatorName,
-						Weight:  weight,
-						KeySLI:  keySli,
-						Pass:    passSLOs,
-						Warning: warningSLOs,
-					}
-					dashboardSLO.Objectives = append(dashboardSLO.Objectives, sloDefinition)
+				// add this to our SLI Indicator JSON in case we need to generate an SLI.yaml
+				// in that case we also need to mask it with USQL, TITLE_TYPE, DIMENSIONNAME
+				dashboardSLI.Indicators[indicatorName] = fmt.Sprintf("USQL;%s;%s;%s", getUSQLTileTypeForValue(tile.Type, dimensionColumn, valueColumns, usqlValue), usqlValue.Dimension, tile.Query)
+
+				// lets add the SLO definitin in case we need to generate an SLO.yaml
+				sloDefinition := &keptncommon.SLO{
+					SLI:     indicatorName,
+					Weight:  weight,
+					KeySLI:  keySli,
+					Pass:    passSLOs,
+					Warning: warningSLOs,
 				}
+				dashboardSLO.Objectives = append(dashboardSLO.Objectives, sloDefinition)
 			}
 		}
 	}
+	finishTileReport()
 
-	return dashboardLinkAsLabel, dashboardJSON, dashboardSLI, dashboardSLO, sliResults, nil
+	return dashboardSLI, dashboardSLO, sliResults
+}
+
+/**
+ * AddMissingSLIResults queries the value of every indicator that has no SLIResult yet and adds it to sliResults
+ * With writeMode merge the sli.yaml & slo.yaml keep user-authored indicators & objectives next to those generated from a dashboard - a reparsed dashboard only returns SLIResults for its own indicators
+ * The other indicators are queried based on ph.CustomQueries. Indicators that couldnt be queried are added as failed SLIResults
+ */
+func (ph *Handler) AddMissingSLIResults(indicators []string, sliResults []*keptnv2.SLIResult, startUnix time.Time, endUnix time.Time) []*keptnv2.SLIResult {
+	existingResults := map[string]bool{}
+	for _, sliResult := range sliResults {
+		existingResults[sliResult.Metric] = true
+	}
+
+	for _, indicator := range indicators {
+		if existingResults[indicator] {
+			continue
+		}
+		existingResults[indicator] = true
+
+		log.WithField("indicator", indicator).Info("Fetching indicator that isnt part of the dashboard")
+		sliValue, err := ph.GetSLIValue(indicator, startUnix, endUnix)
+		if err != nil {
+			log.WithError(err).Error("GetSLIValue failed")
+			sliResults = append(sliResults, &keptnv2.SLIResult{
+				Metric:  indicator,
+				Value:   0,
+				Success: false,
+				Message: err.Error(),
+			})
+			continue
+		}
+
+		sliResults = append(sliResults, &keptnv2.SLIResult{
+			Metric:  indicator,
+			Value:   sliValue,
+			Success: true,
+		})
+	}
+
+	return sliResults
 }
 
 /**
@@ -1914,50 +3941,35 @@ func (ph *Handler) GetSLIValue(metric string, startUnix time.Time, endUnix time.
 		actualMetricValue = 0.0
 	)
 
-	//
 	// USQL: lets check whether this is USQL or regular Metric Query
-	if strings.HasPrefix(metricsQuery, "USQL;") {
-		// In this case we need to parse USQL;TILE_TYPE;DIMENSION;QUERY
-		querySplits := strings.Split(metricsQuery, ";")
-		if len(querySplits) != 4 {
-			return 0, fmt.Errorf("USQL Query incorrect format: %s", metricsQuery)
-		}
-
-		tileName := querySplits[1]
-		requestedDimensionName := querySplits[2]
-		usqlRawQuery := querySplits[3]
+	// USQL;TILE_TYPE;DIMENSION;QUERY, the shorthand USQL;COLUMN;QUERY or a bare statement, e.g: SELECT count(*) FROM usersession
+	usqlDefinition, err := parseUSQLSLIDefinition(metricsQuery)
+	if err != nil {
+		return 0, err
+	}
 
-		usql := ph.BuildDynatraceUSQLQuery(usqlRawQuery, startUnix, endUnix)
+	if usqlDefinition != nil {
+		usql := ph.BuildDynatraceUSQLQuery(usqlDefinition.Query, startUnix, endUnix)
 		usqlResult, err := ph.ExecuteUSQLQuery(usql)
 
 		if err != nil {
 			return 0, fmt.Errorf("Error executing USQL Query %v", err)
 		}
 
-		for _, rowValue := range usqlResult.Values {
-			dimensionName := ""
-			dimensionValue := 0.0
-
-			if tileName == "SINGLE_VALUE" {
-				dimensionValue = rowValue[0].(float64)
-			} else if tileName == "PIE_CHART" {
-				dimensionName = rowValue[0].(string)
-				dimensionValue = rowValue[1].(float64)
-			} else if tileName == "COLUMN_CHART" {
-				dimensionName = rowValue[0].(string)
-				dimensionValue = rowValue[1].(float64)
-			} else if tileName == "TABLE" {
-				dimensionName = rowValue[0].(string)
-				dimensionValue = rowValue[len(rowValue)-1].(float64)
-			} else {
-				log.WithField("tileName", tileName).Debug("Unsupported USQL Tile Type")
-				continue
-			}
+		usqlValues, err := DecodeUSQLResult(usqlResult, usqlDefinition.TileType, usqlDefinition.DimensionColumn, usqlDefinition.ValueColumns)
+		if err != nil {
+			return 0, fmt.Errorf("Error decoding USQL result %v", err)
+		}
 
+		for _, usqlValue := range usqlValues {
 			// did we find the value we were looking for?
-			if strings.Compare(dimensionName, requestedDimensionName) == 0 {
+			if strings.Compare(usqlValue.Dimension, usqlDefinition.Dimension) == 0 {
+				if usqlValue.Err != nil {
+					return 0, fmt.Errorf("Error decoding USQL result %v", usqlValue.Err)
+				}
 				metricIDExists = true
-				actualMetricValue = dimensionValue
+				actualMetricValue = usqlValue.Value
+				break
 			}
 		}
 		//
@@ -2010,56 +4022,124 @@ func (ph *Handler) GetSLIValue(metric string, startUnix time.Time, endUnix time.
 		metricIDExists = true
 		actualMetricValue = float64(problemQueryResult.TotalCount)
 	} else {
-		metricUnit := ""
-
-		//
-		// lets first start to query for the MV2 prefix, e.g: MV2;byte;actualQuery
-		// if it starts with MV2 we extract metric unit and the actual query
-		if strings.HasPrefix(metricsQuery, "MV2;") {
-			metricsQuery = metricsQuery[4:]
-			queryStartIndex := strings.Index(metricsQuery, ";")
-			metricUnit = metricsQuery[:queryStartIndex]
-			metricsQuery = metricsQuery[queryStartIndex+1:]
+		metricIDExists, actualMetricValue, err = ph.getMetricsQueryValue(metricsQuery, startUnix, endUnix)
+		if err != nil {
+			return 0, err
 		}
+	}
 
-		//
-		// In this case we are querying regular MEtrics
-		// now we are enriching it with all the additonal parameters, e.g: time, filters ...
-		metricsQuery, metricID, err := ph.BuildDynatraceMetricsQuery(metricsQuery, startUnix, endUnix)
+	if !metricIDExists {
+		return 0, fmt.Errorf("Not able to query identifier %s from Dynatrace", metric)
+	}
+
+	return actualMetricValue, nil
+}
+
+/**
+ * getMetricsQueryValue
+ * Queries the value of a metrics query, e.g: MV2;MicroSecond;metricSelector=... or metricSelector=..., on the timeframe defined by the options of its metric unit
+ * Returns whether the result contained the queried metric and its value
+ */
+func (ph *Handler) getMetricsQueryValue(metricsQuery string, startUnix time.Time, endUnix time.Time) (bool, float64, error) {
+	metricUnit := ""
+
+	//
+	// lets first start to query for the MV2 prefix, e.g: MV2;byte;actualQuery
+	// if it starts with MV2 we extract metric unit and the actual query
+	if strings.HasPrefix(metricsQuery, "MV2;") {
+		metricsQuery = metricsQuery[4:]
+		queryStartIndex := strings.Index(metricsQuery, ";")
+		metricUnit = metricsQuery[:queryStartIndex]
+		metricsQuery = metricsQuery[queryStartIndex+1:]
+	}
+
+	// the metric unit can move the timeframe, e.g: MV2;Count,shift=-7d;metricSelector=...
+	startUnix, endUnix, err := getMetricUnitTimeframe(metricUnit, startUnix, endUnix)
+	if err != nil {
+		return false, 0, err
+	}
+
+	metricIDExists, value, err := ph.queryMetricValue(metricsQuery, metricUnit, startUnix, endUnix)
+	if err != nil || !metricIDExists {
+		return metricIDExists, value, err
+	}
+
+	// reference=-7d divides the value by the value of the shifted timeframe
+	if reference, found := getMetricUnitOption(metricUnit, MetricUnitOptionReference); found {
+		_, offset, err := parseRelativeTimeframe(reference)
 		if err != nil {
-			return 0, err
+			return false, 0, err
 		}
-		result, err := ph.ExecuteMetricsAPIQuery(metricsQuery)
 
+		referenceExists, referenceValue, err := ph.queryMetricValue(metricsQuery, metricUnit, startUnix.Add(offset), endUnix.Add(offset))
 		if err != nil {
-			return 0, fmt.Errorf("Dynatrace Metrics API returned an error: %s. This was the query executed: %s", err.Error(), metricsQuery)
+			return false, 0, err
+		}
+		if !referenceExists || referenceValue == 0 {
+			return false, 0, fmt.Errorf("Couldnt calculate ratio as there is no reference value %s for query: %s", reference, metricsQuery)
 		}
 
-		if result != nil {
-			for _, i := range result.Result {
+		value = value / referenceValue
+	}
 
-				if ph.isMatchingMetricID(i.MetricID, metricID) {
-					metricIDExists = true
+	return true, value, nil
+}
 
-					if len(i.Data) != 1 {
-						jsonString, _ := json.Marshal(i)
-						return 0, fmt.Errorf("Dynatrace Metrics API returned %d result values, expected 1 for query: %s.\nPlease ensure the response contains exactly one value (e.g., by using :merge(0):avg for the metric). Here is the output for troubleshooting: %s", len(i.Data), metricsQuery, string(jsonString))
-					}
+/**
+ * queryMetricValue
+ * Executes a metrics query for the timeframe and returns whether the result contained the queried metric and its scaled value
+ */
+func (ph *Handler) queryMetricValue(metricsQuery string, metricUnit string, startUnix time.Time, endUnix time.Time) (bool, float64, error) {
+	metricIDExists := false
+	actualMetricValue := 0.0
+
+	//
+	// In this case we are querying regular MEtrics
+	// now we are enriching it with all the additonal parameters, e.g: time, filters ...
+	metricsQuery, metricID, err := ph.BuildDynatraceMetricsQuery(metricsQuery, startUnix, endUnix)
+	if err != nil {
+		return false, 0, err
+	}
+	result, err := ph.ExecuteMetricsAPIQuery(metricsQuery)
+
+	if err != nil {
+		return false, 0, fmt.Errorf("Dynatrace Metrics API returned an error: %s. This was the query executed: %s", err.Error(), metricsQuery)
+	}
+
+	if result != nil {
+		for _, i := range result.Result {
+
+			if ph.isMatchingMetricID(i.MetricID, metricID) {
+				metricIDExists = true
 
-					actualMetricValue = i.Data[0].Values[0]
-					break
+				if len(i.Data) != 1 {
+					jsonString, _ := json.Marshal(i)
+					return false, 0, fmt.Errorf("Dynatrace Metrics API returned %d result values, expected 1 for query: %s.\nPlease ensure the response contains exactly one value (e.g., by using :merge(0):avg for the metric). Here is the output for troubleshooting: %s", len(i.Data), metricsQuery, string(jsonString))
 				}
+
+				// with a resolution other than Inf we get multiple values - just as when we generated the SLI we take the average
+				actualMetricValue = averageValues(i.Data[0].Values)
+				break
 			}
 		}
-
-		actualMetricValue = scaleData(metricID, metricUnit, actualMetricValue)
 	}
 
-	if !metricIDExists {
-		return 0, fmt.Errorf("Not able to query identifier %s from Dynatrace", metric)
-	}
+	actualMetricValue = scaleData(metricID, metricUnit, actualMetricValue)
+	actualMetricValue = normalizeDataToRate(metricID, metricUnit, actualMetricValue, startUnix, endUnix)
 
-	return actualMetricValue, nil
+	return metricIDExists, actualMetricValue, nil
+}
+
+// averageValues returns the average of all values of a metric result. With resolution=Inf this is the one and only value
+func averageValues(values []float64) float64 {
+	if len(values) == 0 {
+		return 0
+	}
+	value := 0.0
+	for _, singleValue := range values {
+		value = value + singleValue
+	}
+	return value / float64(len(values))
 }
 
 // scaleData
@@ -2067,6 +4147,20 @@ func (ph *Handler) GetSLIValue(metric string, startUnix time.Time, endUnix time.
 // Right now this method scales microseconds to milliseconds and bytes to Kilobytes
 // At a later stage we should extend this with more conversions and even think of allowing custom scale targets, e.g: Byte to MegaByte
 func scaleData(metricID string, unit string, value float64) float64 {
+	// the unit can have options, e.g: Percent,ratio=other
+	unitOptions := strings.Split(unit, ",")
+	unit = unitOptions[0]
+	for _, unitOption := range unitOptions[1:] {
+		if unitOption == MetricUnitOptionOtherRatio {
+			// the other ratio is 1 minus the ratio of interest - for percentages that is 100%
+			if strings.Compare(unit, "Percent") == 0 {
+				value = 100.0 - value
+			} else {
+				value = 1.0 - value
+			}
+		}
+	}
+
 	if (strings.Compare(unit, "MicroSecond") == 0) || strings.Contains(metricID, "builtin:service.response.time") {
 		// scale from microseconds to milliseconds
 		return value / 1000.0
@@ -2118,18 +4212,22 @@ func (ph *Handler) getTimeseriesConfig(metric string) (string, error) {
 
 	// default SLI configs
 	// Switched to new metric v2 query language as discussed here: https://github.com/keptn-contrib/dynatrace-sli-service/issues/91
+	var metricSelector string
 	switch metric {
 	case Throughput:
-		return "metricSelector=builtin:service.requestCount.total:merge(0):sum&entitySelector=type(SERVICE),tag(keptn_project:$PROJECT),tag(keptn_stage:$STAGE),tag(keptn_service:$SERVICE),tag(keptn_deployment:$DEPLOYMENT)", nil
+		metricSelector = "builtin:service.requestCount.total:merge(0):sum"
 	case ErrorRate:
-		return "metricSelector=builtin:service.errors.total.rate:merge(0):avg&entitySelector=type(SERVICE),tag(keptn_project:$PROJECT),tag(keptn_stage:$STAGE),tag(keptn_service:$SERVICE),tag(keptn_deployment:$DEPLOYMENT)", nil
+		metricSelector = "builtin:service.errors.total.rate:merge(0):avg"
 	case ResponseTimeP50:
-		return "metricSelector=builtin:service.response.time:merge(0):percentile(50)&entitySelector=type(SERVICE),tag(keptn_project:$PROJECT),tag(keptn_stage:$STAGE),tag(keptn_service:$SERVICE),tag(keptn_deployment:$DEPLOYMENT)", nil
+		metricSelector = "builtin:service.response.time:merge(0):percentile(50)"
 	case ResponseTimeP90:
-		return "metricSelector=builtin:service.response.time:merge(0):percentile(90)&entitySelector=type(SERVICE),tag(keptn_project:$PROJECT),tag(keptn_stage:$STAGE),tag(keptn_service:$SERVICE),tag(keptn_deployment:$DEPLOYMENT)", nil
+		metricSelector = "builtin:service.response.time:merge(0):percentile(90)"
 	case ResponseTimeP95:
-		return "metricSelector=builtin:service.response.time:merge(0):percentile(95)&entitySelector=type(SERVICE),tag(keptn_project:$PROJECT),tag(keptn_stage:$STAGE),tag(keptn_service:$SERVICE),tag(keptn_deployment:$DEPLOYMENT)", nil
+		metricSelector = "builtin:service.response.time:merge(0):percentile(95)"
 	default:
 		return "", fmt.Errorf("Unsupported SLI metric %s", metric)
 	}
+
+	// the entitySelector is configurable through dynatrace.conf.yaml:entitySelector and defaults to the keptn tags
+	return fmt.Sprintf("metricSelector=%s&entitySelector=%s", metricSelector, common.GetEntitySelectorTemplate(ph.EntitySelector)), nil
 }
@@ -2,6 +2,7 @@ package dynatrace
 
 import (
 	"bytes"
+	"encoding/json"
 	"fmt"
 	"io"
 	"io/ioutil"
@@ -25,6 +26,7 @@ import (
 	keptn "github.com/keptn/go-utils/pkg/lib"
 	keptnv2 "github.com/keptn/go-utils/pkg/lib/v0_2_0"
 	"golang.org/x/net/context"
+	"gopkg.in/yaml.v2"
 
 	"github.com/keptn-contrib/dynatrace-sli-service/pkg/common"
 )
@@ -35,6 +37,12 @@ const QUALTIYGATE_SERVICE = "evalservice"
 const QUALITYGATE_STAGE = "qualitystage"
 
 // Mocking Http Responses
+// testingSetResourceStore replaces the resource store until the test is finished
+func testingSetResourceStore(t *testing.T, store common.ResourceStore) {
+	previousStore := common.SetResourceStore(store)
+	t.Cleanup(func() { common.SetResourceStore(previousStore) })
+}
+
 // testingDynatraceHTTPClient builds a test client with a httptest server that responds to specific Dynatrace REST API Calls
 func testingDynatraceHTTPClient() (*http.Client, string, func()) {
 
@@ -61,10 +69,12 @@ func testingDynatraceHTTPClient() (*http.Client, string, func()) {
 
 		// we handle these if the URL "starts with"
 		startsWithUrlToResponseFileMap := map[string]string{
-			"/api/v2/metrics/query":    "./testfiles/test_get_metrics_query.json",
-			"/api/v2/slo":              "./testfiles/test_get_slo_id.json",
-			"/api/v2/problems":         "./testfiles/test_get_problems.json",
-			"/api/v2/securityProblems": "./testfiles/test_get_securityproblems.json",
+			"/api/v2/metrics/query":                  "./testfiles/test_get_metrics_query.json",
+			"/api/v2/slo":                            "./testfiles/test_get_slo_id.json",
+			"/api/v2/problems":                       "./testfiles/test_get_problems.json",
+			"/api/v2/securityProblems":               "./testfiles/test_get_securityproblems.json",
+			"/api/v2/entities":                       "./testfiles/test_get_entities_synthetic.json",
+			"/api/v1/userSessionQueryLanguage/table": "./testfiles/test_get_usql_table.json",
 		}
 
 		for url, file := range completeUrlMatchToResponseFileMap {
@@ -223,6 +233,33 @@ func TestFindDynatraceDashboardNoneExistingDashboard(t *testing.T) {
 	}
 }
 
+func TestGetDashboardTagMatchRank(t *testing.T) {
+	keptnEvent := testingGetKeptnEvent(QUALITYGATE_PROJECT, QUALITYGATE_STAGE, QUALTIYGATE_SERVICE, "", "")
+
+	tests := []struct {
+		name string
+		tags []string
+		want int
+	}{
+		{"no tags", []string{}, 0},
+		{"other project", []string{"keptn_project:otherproject"}, 0},
+		{"project-wide", []string{"keptn_project:qualitygate", "sometag"}, 1},
+		{"stage-wide", []string{"keptn_project:qualitygate", "keptn_stage:qualitystage"}, 2},
+		{"other stage", []string{"keptn_project:qualitygate", "keptn_stage:otherstage"}, 0},
+		{"one of multiple stages", []string{"keptn_project:qualitygate", "keptn_stage:otherstage", "keptn_stage:QualityStage"}, 2},
+		{"service-specific", []string{"keptn_project:qualitygate", "keptn_service:evalservice"}, 3},
+		{"other service", []string{"keptn_project:qualitygate", "keptn_stage:qualitystage", "keptn_service:otherservice"}, 0},
+		{"service-specific for stage", []string{"keptn_project:qualitygate", "keptn_stage:qualitystage", "keptn_service:evalservice"}, 4},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := getDashboardTagMatchRank(tt.tags, keptnEvent); got != tt.want {
+				t.Errorf("getDashboardTagMatchRank() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
 func TestLoadDynatraceDashboardWithQUERY(t *testing.T) {
 	keptnEvent := testingGetKeptnEvent(QUALITYGATE_PROJECT, QUALITYGATE_STAGE, QUALTIYGATE_SERVICE, "", "")
 	dh, _, _, teardown := testingGetDynatraceHandler(keptnEvent)
@@ -244,6 +281,46 @@ func TestLoadDynatraceDashboardWithQUERY(t *testing.T) {
 	}
 }
 
+func TestFindDynatraceDashboardByNameTemplate(t *testing.T) {
+	keptnEvent := testingGetKeptnEvent(QUALITYGATE_PROJECT, QUALITYGATE_STAGE, QUALTIYGATE_SERVICE, "", "")
+	dh, _, _, teardown := testingGetDynatraceHandler(keptnEvent)
+	defer teardown()
+
+	tests := []struct {
+		name       string
+		dashboard  string
+		owner      string
+		sharedOnly bool
+		want       string
+		wantErr    bool
+	}{
+		{"name with placeholders", "name:KQG;project=$PROJECT;service=$SERVICE;stage=$STAGE", "", false, QUALITYGATE_DASHBOARD_ID, false},
+		{"name is case insensitive", "name:kqg;project=$PROJECT;service=$SERVICE;stage=$STAGE", "", false, QUALITYGATE_DASHBOARD_ID, false},
+		{"name regex with placeholders", "nameRegex:^KQG;project=$PROJECT;.*", "", false, QUALITYGATE_DASHBOARD_ID, false},
+		{"name regex matching multiple dashboards", "nameRegex:.*", "", false, "", true},
+		{"no matching name", "name:Quality Gate - $SERVICE ($STAGE)", "", false, "", true},
+		{"invalid name regex", "nameRegex:KQG;(", "", false, "", true},
+		{"name with owner", "name:KQG;project=$PROJECT;service=$SERVICE;stage=$STAGE", "anybody", false, QUALITYGATE_DASHBOARD_ID, false},
+		{"name with other owner", "name:KQG;project=$PROJECT;service=$SERVICE;stage=$STAGE", "somebodyelse", false, "", true},
+		{"name of dashboard that is not shared", "name:KQG;project=$PROJECT;service=$SERVICE;stage=$STAGE", "", true, "", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dh.DashboardOwner = tt.owner
+			dh.DashboardSharedOnly = tt.sharedOnly
+
+			got, err := dh.findDynatraceDashboardByNameTemplate(keptnEvent, tt.dashboard)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("findDynatraceDashboardByNameTemplate() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if got != tt.want {
+				t.Errorf("findDynatraceDashboardByNameTemplate() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
 func TestLoadDynatraceDashboardWithID(t *testing.T) {
 	keptnEvent := testingGetKeptnEvent(QUALITYGATE_PROJECT, QUALITYGATE_STAGE, QUALTIYGATE_SERVICE, "", "")
 	dh, _, _, teardown := testingGetDynatraceHandler(keptnEvent)
@@ -304,6 +381,560 @@ func TestGetEntitySelectorFromEntityFilter(t *testing.T) {
 	}
 }
 
+func TestGetDataExplorerFilter(t *testing.T) {
+	tests := []struct {
+		name             string
+		filterBy         string
+		wantFilter       string
+		wantEntityFilter string
+		wantErr          bool
+	}{
+		{
+			name:             "single entity",
+			filterBy:         `{"filterOperator":"AND","nestedFilters":[{"filter":"dt.entity.service","filterType":"DIMENSION","filterOperator":"OR","nestedFilters":[],"criteria":[{"value":"SERVICE-FFD81F003E39B468","evaluator":"EQ"}]}],"criteria":[]}`,
+			wantEntityFilter: "&entitySelector=entityId(SERVICE-FFD81F003E39B468)",
+		},
+		{
+			name:             "multiple entities",
+			filterBy:         `{"filterOperator":"AND","nestedFilters":[{"filter":"dt.entity.service","filterType":"DIMENSION","filterOperator":"OR","nestedFilters":[],"criteria":[{"value":"SERVICE-1","evaluator":"EQ"},{"value":"SERVICE-2","evaluator":"EQ"}]}],"criteria":[]}`,
+			wantEntityFilter: "&entitySelector=entityId(SERVICE-1,SERVICE-2)",
+		},
+		{
+			name:       "single dimension",
+			filterBy:   `{"filterOperator":"AND","nestedFilters":[{"filter":"Test Step","filterType":"DIMENSION","filterOperator":"OR","nestedFilters":[],"criteria":[{"value":"Login","evaluator":"EQ"}]}],"criteria":[]}`,
+			wantFilter: `:filter(eq("Test Step",Login))`,
+		},
+		{
+			name:             "entity and multiple dimensions",
+			filterBy:         `{"filterOperator":"AND","nestedFilters":[{"filter":"dt.entity.service","filterOperator":"OR","nestedFilters":[],"criteria":[{"value":"SERVICE-1","evaluator":"EQ"}]},{"filter":"http.method","filterOperator":"OR","nestedFilters":[],"criteria":[{"value":"GET","evaluator":"EQ"},{"value":"POST","evaluator":"EQ"}]},{"filter":"url","filterOperator":"AND","nestedFilters":[],"criteria":[{"value":"/api","evaluator":"PREFIX"},{"value":"/api/health","evaluator":"NE"}]}],"criteria":[]}`,
+			wantFilter:       ":filter(and(or(eq(http.method,GET),eq(http.method,POST)),and(prefix(url,/api),ne(url,/api/health))))",
+			wantEntityFilter: "&entitySelector=entityId(SERVICE-1)",
+		},
+		{
+			name:       "entity within top level OR",
+			filterBy:   `{"filterOperator":"OR","nestedFilters":[{"filter":"dt.entity.service","filterOperator":"OR","nestedFilters":[],"criteria":[{"value":"SERVICE-1","evaluator":"EQ"}]},{"filter":"http.method","filterOperator":"OR","nestedFilters":[],"criteria":[{"value":"GET","evaluator":"EQ"}]}],"criteria":[]}`,
+			wantFilter: ":filter(or(eq(dt.entity.service,SERVICE-1),eq(http.method,GET)))",
+		},
+		{
+			name:       "nested filters",
+			filterBy:   `{"filterOperator":"AND","nestedFilters":[{"filterOperator":"OR","nestedFilters":[{"filter":"a","filterOperator":"OR","nestedFilters":[],"criteria":[{"value":"1","evaluator":"EQ"}]},{"filter":"b","filterOperator":"OR","nestedFilters":[],"criteria":[{"value":"x,y","evaluator":"EQ"}]}],"criteria":[]},{"filter":"c","filterOperator":"OR","nestedFilters":[],"criteria":[{"value":"","evaluator":"EXISTS_KEY"}]}],"criteria":[]}`,
+			wantFilter: `:filter(and(or(eq(a,1),eq(b,"x,y")),existsKey(c)))`,
+		},
+		{
+			name:     "unsupported evaluator",
+			filterBy: `{"filterOperator":"AND","nestedFilters":[{"filter":"a","filterOperator":"OR","nestedFilters":[],"criteria":[{"value":"1","evaluator":"GREATER"}]}],"criteria":[]}`,
+			wantErr:  true,
+		},
+		{
+			name:     "empty filter",
+			filterBy: `{"filterOperator":"AND","nestedFilters":[],"criteria":[]}`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			filterBy := NestedFilterDataExplorer{}
+			if err := json.Unmarshal([]byte(tt.filterBy), &filterBy); err != nil {
+				t.Fatal(err)
+			}
+
+			gotFilter, gotEntityFilter, err := getDataExplorerFilter(filterBy)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("getDataExplorerFilter() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if gotFilter != tt.wantFilter {
+				t.Errorf("getDataExplorerFilter() filter = %v, want %v", gotFilter, tt.wantFilter)
+			}
+			if gotEntityFilter != tt.wantEntityFilter {
+				t.Errorf("getDataExplorerFilter() entityFilter = %v, want %v", gotEntityFilter, tt.wantEntityFilter)
+			}
+		})
+	}
+}
+
+func TestGenerateMetricQueryFromDataExplorer(t *testing.T) {
+	keptnEvent := testingGetKeptnEvent(QUALITYGATE_PROJECT, QUALITYGATE_STAGE, QUALTIYGATE_SERVICE, "", "")
+	dh, _, _, teardown := testingGetDynatraceHandler(keptnEvent)
+	defer teardown()
+
+	tests := []struct {
+		name                string
+		queries             string
+		resolution          string
+		managementZone      string
+		wantQuery           string
+		wantMetricSelector  string
+		wantUnit            string
+		wantSplitDimensions []SplitDimension
+		wantErr             bool
+	}{
+		{
+			name:                "builder mode with space and time aggregation",
+			queries:             `[{"id":"A","metric":"jmeter.usermetrics.transaction.meantime","spaceAggregation":"MAX","timeAggregation":"AVG","splitBy":["transaction"]}]`,
+			wantQuery:           "metricSelector=jmeter.usermetrics.transaction.meantime:max:fold(avg):names",
+			wantUnit:            "Unspecified",
+			wantSplitDimensions: []SplitDimension{{Key: "transaction"}},
+		},
+		{
+			name:           "builder mode with percentile, resolution and management zone",
+			queries:        `[{"id":"A","metric":"builtin:service.response.time","spaceAggregation":"PERCENTILE_90","timeAggregation":"DEFAULT","splitBy":[]}]`,
+			resolution:     "1h",
+			managementZone: ",mzId(123)",
+			wantQuery:      "metricSelector=builtin:service.response.time:merge(0):percentile(90):names&mzSelector=mzId(123)&resolution=1h",
+			wantUnit:       "MicroSecond",
+		},
+		{
+			name:                "code mode",
+			queries:             `[{"id":"A","metricSelector":"builtin:service.response.time:splitBy(\"dt.entity.service\"):avg:auto:sort(value(avg,descending)):limit(10)","spaceAggregation":"AUTO","timeAggregation":"DEFAULT"}]`,
+			wantQuery:           `metricSelector=builtin:service.response.time:splitBy("dt.entity.service"):avg:auto:sort(value(avg,descending)):limit(10):names`,
+			wantUnit:            "MicroSecond",
+			wantSplitDimensions: []SplitDimension{{Key: "dt.entity.service", EntityDimension: true}},
+		},
+		{
+			name:      "expression of other queries",
+			queries:   `[{"id":"C","metricSelector":"(A / B) * 100"},{"id":"A","metric":"builtin:service.errors.server.rate","spaceAggregation":"AVG","timeAggregation":"DEFAULT","splitBy":[],"filterBy":{"filterOperator":"AND","nestedFilters":[{"filter":"dt.entity.service","filterOperator":"OR","nestedFilters":[],"criteria":[{"value":"SERVICE-1","evaluator":"EQ"}]}],"criteria":[]}},{"id":"B","metricSelector":"builtin:service.requestCount.total:splitBy():value"}]`,
+			wantQuery: "metricSelector=(((builtin:service.errors.server.rate:merge(0):filter(eq(dt.entity.service,SERVICE-1)):avg) / (builtin:service.requestCount.total:splitBy():value)) * 100):names",
+			wantUnit:  "Unspecified",
+		},
+		{
+			name:               "expression with an addition",
+			queries:            `[{"id":"C","metricSelector":"A + B"},{"id":"A","metricSelector":"builtin:service.errors.server.count:splitBy():value"},{"id":"B","metricSelector":"builtin:service.errors.client.count:splitBy():value"}]`,
+			wantQuery:          "metricSelector=((builtin:service.errors.server.count:splitBy():value) %2B (builtin:service.errors.client.count:splitBy():value)):names",
+			wantMetricSelector: "((builtin:service.errors.server.count:splitBy():value) + (builtin:service.errors.client.count:splitBy():value)):names",
+			wantUnit:           "Unspecified",
+		},
+		{
+			name:    "expression with unknown query",
+			queries: `[{"id":"C","metricSelector":"A + D"},{"id":"A","metricSelector":"builtin:service.requestCount.total:splitBy():value"}]`,
+			wantErr: true,
+		},
+		{
+			name:    "unsupported aggregation",
+			queries: `[{"id":"A","metric":"builtin:service.response.time","spaceAggregation":"RATE","timeAggregation":"DEFAULT","splitBy":[]}]`,
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var queries []DataExplorerQuery
+			if err := json.Unmarshal([]byte(tt.queries), &queries); err != nil {
+				t.Fatal(err)
+			}
+
+			gotMetricSelector, gotUnit, gotQuery, gotFullQuery, gotSplitDimensions, err := dh.GenerateMetricQueryFromDataExplorer(queries[0], queries, tt.resolution, tt.managementZone, time.Unix(1571649084, 0).UTC(), time.Unix(1571649085, 0).UTC())
+			if (err != nil) != tt.wantErr {
+				t.Errorf("GenerateMetricQueryFromDataExplorer() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if gotQuery != tt.wantQuery {
+				t.Errorf("GenerateMetricQueryFromDataExplorer() query = %v, want %v", gotQuery, tt.wantQuery)
+			}
+			if tt.wantMetricSelector != "" && gotMetricSelector != tt.wantMetricSelector {
+				t.Errorf("GenerateMetricQueryFromDataExplorer() metricSelector = %v, want %v", gotMetricSelector, tt.wantMetricSelector)
+			}
+			if gotUnit != tt.wantUnit {
+				t.Errorf("GenerateMetricQueryFromDataExplorer() unit = %v, want %v", gotUnit, tt.wantUnit)
+			}
+			if !tt.wantErr && strings.Count(gotFullQuery, "resolution=") != 1 {
+				t.Errorf("GenerateMetricQueryFromDataExplorer() full query should contain exactly one resolution: %v", gotFullQuery)
+			}
+			if fmt.Sprint(gotSplitDimensions) != fmt.Sprint(tt.wantSplitDimensions) {
+				t.Errorf("GenerateMetricQueryFromDataExplorer() splitDimensions = %v, want %v", gotSplitDimensions, tt.wantSplitDimensions)
+			}
+		})
+	}
+}
+
+func TestGetThresholdCriteria(t *testing.T) {
+	tests := []struct {
+		name         string
+		visualConfig string
+		queryID      string
+		wantPass     []string
+		wantWarning  []string
+	}{
+		{
+			name:         "no visual config",
+			visualConfig: "",
+			queryID:      "A",
+		},
+		{
+			name:         "lower is better",
+			visualConfig: `{"type":"GRAPH_CHART","thresholds":[{"queryId":"","rules":[{"value":0,"color":"#7dc540"},{"value":500,"color":"#f5d30f"},{"value":1000,"color":"#dc172a"}]}]}`,
+			queryID:      "A",
+			wantPass:     []string{"<500"},
+			wantWarning:  []string{"<1000"},
+		},
+		{
+			name:         "higher is better",
+			visualConfig: `{"type":"SINGLE_VALUE","thresholds":[{"queryId":"A","rules":[{"value":95,"color":"#7DC540"},{"value":90.5,"color":"#f5d30f"},{"value":0,"color":"#dc172a"}]}]}`,
+			queryID:      "A",
+			wantPass:     []string{">=95"},
+			wantWarning:  []string{">=90.5"},
+		},
+		{
+			name:         "no yellow",
+			visualConfig: `{"type":"GRAPH_CHART","global":{"thresholds":[{"queryId":"","rules":[{"value":0,"color":"#7dc540"},{"color":"#f5d30f"},{"value":5,"color":"#dc172a"}]}]}}`,
+			queryID:      "A",
+			wantPass:     []string{"<5"},
+		},
+		{
+			name:         "threshold of the query wins",
+			visualConfig: `{"type":"GRAPH_CHART","thresholds":[{"queryId":"","rules":[{"value":0,"color":"#7dc540"},{"value":5,"color":"#dc172a"}]},{"queryId":"B","rules":[{"value":0,"color":"#7dc540"},{"value":10,"color":"#dc172a"}]}]}`,
+			queryID:      "B",
+			wantPass:     []string{"<10"},
+		},
+		{
+			name:         "threshold of another query",
+			visualConfig: `{"type":"GRAPH_CHART","thresholds":[{"queryId":"B","rules":[{"value":0,"color":"#7dc540"},{"value":10,"color":"#dc172a"}]}]}`,
+			queryID:      "A",
+		},
+		{
+			name:         "values not in order",
+			visualConfig: `{"type":"GRAPH_CHART","thresholds":[{"queryId":"","rules":[{"value":0,"color":"#7dc540"},{"value":2000,"color":"#f5d30f"},{"value":1000,"color":"#dc172a"}]}]}`,
+			queryID:      "A",
+		},
+		{
+			name:         "no values",
+			visualConfig: `{"type":"GRAPH_CHART","thresholds":[{"queryId":"","visible":true,"rules":[{"color":"#7dc540"},{"color":"#f5d30f"},{"color":"#dc172a"}]}]}`,
+			queryID:      "A",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var visualConfig *VisualConfig
+			if tt.visualConfig != "" {
+				if err := json.Unmarshal([]byte(tt.visualConfig), &visualConfig); err != nil {
+					t.Fatalf("Couldnt parse visual config: %v", err)
+				}
+			}
+
+			gotPass, gotWarning := getThresholdCriteria(visualConfig, tt.queryID)
+			if !reflect.DeepEqual(gotPass, tt.wantPass) {
+				t.Errorf("getThresholdCriteria() pass = %v, want %v", gotPass, tt.wantPass)
+			}
+			if !reflect.DeepEqual(gotWarning, tt.wantWarning) {
+				t.Errorf("getThresholdCriteria() warning = %v, want %v", gotWarning, tt.wantWarning)
+			}
+		})
+	}
+}
+
+func TestProcessSyntheticTestsTile(t *testing.T) {
+	keptnEvent := testingGetKeptnEvent(QUALITYGATE_PROJECT, QUALITYGATE_STAGE, QUALTIYGATE_SERVICE, "", "")
+	dh, _, _, teardown := testingGetDynatraceHandler(keptnEvent)
+	defer teardown()
+
+	dashboardSLI := &SLI{Indicators: map[string]string{}}
+	dashboardSLO := &keptn.ServiceLevelObjectives{}
+	// the unprefixed pass criteria, e.g: a section default, doesnt replace the default SLOs
+	monitors := []string{"SYNTHETIC_TEST-1234567890ABCDEF", "HTTP_CHECK-1234567890ABCDEF"}
+	sliResults := dh.ProcessSyntheticTestsTile("Synthetic monitors;sli=synth;duration.pass=<=2000;weight=2;pass=<=800", monitors, dashboardSLI, dashboardSLO, time.Unix(1571649084, 0).UTC(), time.Unix(1571649085, 0).UTC())
+
+	expectedValues := map[string]float64{
+		"synth_availability_Checkout":     99.5,
+		"synth_duration_Checkout":         1850.0,
+		"synth_availability_Health_Check": 100.0,
+		"synth_duration_Health_Check":     120.0,
+	}
+	if len(sliResults) != len(expectedValues) {
+		t.Fatalf("Expected %d SLIs but got %d", len(expectedValues), len(sliResults))
+	}
+	for _, sliResult := range sliResults {
+		if expectedValue, found := expectedValues[sliResult.Metric]; !found || !sliResult.Success || sliResult.Value != expectedValue {
+			t.Errorf("Unexpected SLI result %s: %v (success: %v, message: %s)", sliResult.Metric, sliResult.Value, sliResult.Success, sliResult.Message)
+		}
+	}
+
+	if len(dashboardSLO.Objectives) != len(expectedValues) {
+		t.Fatalf("Expected %d SLOs but got %d", len(expectedValues), len(dashboardSLO.Objectives))
+	}
+
+	if dashboardSLI.Indicators["synth_availability_Checkout"] != "MV2;Percent;metricSelector=builtin:synthetic.browser.availability.location.total:splitBy():avg&entitySelector=entityId(SYNTHETIC_TEST-1234567890ABCDEF)" {
+		t.Errorf("Unexpected SLI definition: %s", dashboardSLI.Indicators["synth_availability_Checkout"])
+	}
+
+	for _, objective := range dashboardSLO.Objectives {
+		expectedPass := SyntheticAvailabilityDefaultPass
+		if strings.Contains(objective.SLI, "_duration_") {
+			expectedPass = "<=2000"
+		}
+		if len(objective.Pass) != 1 || objective.Pass[0].Criteria[0] != expectedPass || objective.Weight != 2 {
+			t.Errorf("Unexpected SLO for %s: pass %v, weight %d", objective.SLI, objective.Pass, objective.Weight)
+		}
+	}
+
+	// without assigned monitors we dont evaluate all monitors of the tenant
+	if sliResults := dh.ProcessSyntheticTestsTile("Synthetic monitors;sli=synth", nil, dashboardSLI, dashboardSLO, time.Unix(1571649084, 0).UTC(), time.Unix(1571649085, 0).UTC()); len(sliResults) != 0 {
+		t.Errorf("Expected no SLIs for a tile without assigned monitors but got %d", len(sliResults))
+	}
+}
+
+// Synthetic tiles are only included with sli= and only evaluate the monitors assigned to them
+func TestProcessDashboardTilesWithSyntheticTestsTiles(t *testing.T) {
+	keptnEvent := testingGetKeptnEvent(QUALITYGATE_PROJECT, QUALITYGATE_STAGE, QUALTIYGATE_SERVICE, "", "")
+	dh, _, _, teardown := testingGetDynatraceHandler(keptnEvent)
+	defer teardown()
+
+	dashboardJSON := &DynatraceDashboard{}
+	err := json.Unmarshal([]byte(`{
+		"dashboardMetadata": {"name": "KQG"},
+		"tiles": [
+			{"name": "KQG.Section;prefix=web_;pass=<=800", "tileType": "HEADER", "bounds": {"top": 0, "left": 0, "width": 1254, "height": 38}},
+			{"name": "Synthetic monitors", "tileType": "SYNTHETIC_TESTS", "bounds": {"top": 38, "left": 0, "width": 304, "height": 304}, "assignedEntities": ["SYNTHETIC_TEST-1234567890ABCDEF"]},
+			{"name": "Synthetic monitors;sli=all", "tileType": "SYNTHETIC_TESTS", "bounds": {"top": 38, "left": 304, "width": 304, "height": 304}},
+			{"name": "Synthetic monitors;sli=synth", "tileType": "SYNTHETIC_TESTS", "bounds": {"top": 38, "left": 608, "width": 304, "height": 304}, "assignedEntities": ["SYNTHETIC_TEST-1234567890ABCDEF"]}
+		]
+	}`), dashboardJSON)
+	if err != nil {
+		t.Fatalf("Couldnt parse dashboard: %v", err)
+	}
+
+	_, dashboardSLO, sliResults := dh.processDashboardTiles(dashboardJSON, time.Unix(1571649084, 0).UTC(), time.Unix(1571649085, 0).UTC())
+
+	parseReport := dh.DashboardParseReports[0]
+	if parseReport.Tiles[1].Reason != "title doesnt include sli=SLINAME" || parseReport.Tiles[2].Reason != "no synthetic monitors are assigned to the tile" {
+		t.Errorf("Expected the tiles without sli= and without monitors to be skipped but got %+v and %+v", parseReport.Tiles[1], parseReport.Tiles[2])
+	}
+
+	// the mocked entities query returns two monitors
+	if len(sliResults) != 4 || len(dashboardSLO.Objectives) != 4 {
+		t.Fatalf("Expected 4 SLIs and SLOs but got %d and %d", len(sliResults), len(dashboardSLO.Objectives))
+	}
+	for _, objective := range dashboardSLO.Objectives {
+		expectedPass := SyntheticAvailabilityDefaultPass
+		if strings.Contains(objective.SLI, "_duration_") {
+			expectedPass = SyntheticDurationDefaultPass
+		}
+		if !strings.HasPrefix(objective.SLI, "web_synth_") || len(objective.Pass) != 1 || objective.Pass[0].Criteria[0] != expectedPass {
+			t.Errorf("Unexpected SLO for %s: pass %v", objective.SLI, objective.Pass)
+		}
+	}
+}
+
+func TestGetTileSLOStringForKind(t *testing.T) {
+	tileName := "Synthetic;sli=synth;pass=>=98;availability.pass=>=99.9;duration.warning=<=3000;key=true"
+	if got := getTileSLOStringForKind(tileName, "availability"); got != "sli=synth;pass=>=99.9;key=true" {
+		t.Errorf("getTileSLOStringForKind() availability = %s", got)
+	}
+	if got := getTileSLOStringForKind(tileName, "duration"); got != "sli=synth;warning=<=3000;key=true" {
+		t.Errorf("getTileSLOStringForKind() duration = %s", got)
+	}
+}
+
+func TestProcessEntityHealthTile(t *testing.T) {
+	keptnEvent := testingGetKeptnEvent(QUALITYGATE_PROJECT, QUALITYGATE_STAGE, QUALTIYGATE_SERVICE, "", "")
+	dh, _, _, teardown := testingGetDynatraceHandler(keptnEvent)
+	defer teardown()
+
+	dashboardSLI := &SLI{Indicators: map[string]string{}}
+	dashboardSLO := &keptn.ServiceLevelObjectives{}
+	// an unprefixed pass criteria, e.g: a dashboard default for response times, doesnt replace the defaults of the cpu & memory SLIs
+	sliResults := dh.ProcessEntityHealthTile("HOSTS", "Hosts;sli=hosts;cpu.pass=<=70;pass=<=500;warning=<=800", nil, ",mzId(1234)", dashboardSLI, dashboardSLO, time.Unix(1571649084, 0).UTC(), time.Unix(1571649085, 0).UTC())
+
+	expectedValues := map[string]float64{
+		"hosts_cpu_frontend-host":    42.5,
+		"hosts_cpu_backend-host":     85.0,
+		"hosts_memory_frontend-host": 60.0,
+		"hosts_memory_backend-host":  70.0,
+	}
+	if len(sliResults) != len(expectedValues) {
+		t.Fatalf("Expected %d SLIs but got %d", len(expectedValues), len(sliResults))
+	}
+	for _, sliResult := range sliResults {
+		if expectedValue, found := expectedValues[sliResult.Metric]; !found || !sliResult.Success || sliResult.Value != expectedValue {
+			t.Errorf("Unexpected SLI result %s: %v (success: %v, message: %s)", sliResult.Metric, sliResult.Value, sliResult.Success, sliResult.Message)
+		}
+	}
+
+	if len(dashboardSLO.Objectives) != len(expectedValues) {
+		t.Fatalf("Expected %d SLOs but got %d", len(expectedValues), len(dashboardSLO.Objectives))
+	}
+
+	expectedDefinition := "MV2;Percent;metricSelector=builtin:host.cpu.usage:splitBy(\"dt.entity.host\"):avg:names&entitySelector=type(HOST),mzId(1234),entityId(HOST-2222222222222222)"
+	if dashboardSLI.Indicators["hosts_cpu_backend-host"] != expectedDefinition {
+		t.Errorf("Unexpected SLI definition: %s", dashboardSLI.Indicators["hosts_cpu_backend-host"])
+	}
+
+	for _, objective := range dashboardSLO.Objectives {
+		expectedPass := "<=80"
+		if strings.HasPrefix(objective.SLI, "hosts_cpu_") {
+			expectedPass = "<=70"
+		}
+		if len(objective.Pass) != 1 || objective.Pass[0].Criteria[0] != expectedPass || len(objective.Warning) != 1 || objective.Warning[0].Criteria[0] != "<=90" {
+			t.Errorf("Unexpected SLO for %s: pass %v, warning %v", objective.SLI, objective.Pass, objective.Warning)
+		}
+	}
+
+	// the entities assigned to the tile are queried by ID - every SLI definition narrows the entitySelector down to its entity
+	dashboardSLI = &SLI{Indicators: map[string]string{}}
+	dashboardSLO = &keptn.ServiceLevelObjectives{}
+	sliResults = dh.ProcessEntityHealthTile("HOST", "Host;sli=host", []string{"HOST-1111111111111111", "HOST-2222222222222222"}, ",mzId(1234)", dashboardSLI, dashboardSLO, time.Unix(1571649084, 0).UTC(), time.Unix(1571649085, 0).UTC())
+	if len(sliResults) != 4 || len(dashboardSLO.Objectives) != 4 {
+		t.Fatalf("Expected 4 SLIs and SLOs for the assigned entities but got %d and %d", len(sliResults), len(dashboardSLO.Objectives))
+	}
+	expectedDefinition = "MV2;Percent;metricSelector=builtin:host.mem.usage:splitBy(\"dt.entity.host\"):avg:names&entitySelector=entityId(HOST-1111111111111111)"
+	if dashboardSLI.Indicators["host_memory_frontend-host"] != expectedDefinition {
+		t.Errorf("Unexpected SLI definition for an assigned entity: %s", dashboardSLI.Indicators["host_memory_frontend-host"])
+	}
+
+	// tiles of other types are not processed
+	if sliResults := dh.ProcessEntityHealthTile("MARKDOWN", "Hosts;sli=hosts", nil, "", dashboardSLI, dashboardSLO, time.Unix(1571649084, 0).UTC(), time.Unix(1571649085, 0).UTC()); len(sliResults) != 0 {
+		t.Errorf("Expected no SLIs for a markdown tile but got %d", len(sliResults))
+	}
+}
+
+// Dashboard & section default criteria are meant for single metric tiles - they dont replace the per metric defaults of an entity health tile
+func TestProcessDashboardTilesDoesntApplyDefaultCriteriaToEntityHealthTiles(t *testing.T) {
+	keptnEvent := testingGetKeptnEvent(QUALITYGATE_PROJECT, QUALITYGATE_STAGE, QUALTIYGATE_SERVICE, "", "")
+	dh, _, _, teardown := testingGetDynatraceHandler(keptnEvent)
+	defer teardown()
+
+	dashboardJSON := &DynatraceDashboard{}
+	err := json.Unmarshal([]byte(`{
+		"dashboardMetadata": {"name": "KQG"},
+		"tiles": [
+			{"name": "Markdown", "tileType": "MARKDOWN", "markdown": "KQG.Default.Pass=<=500", "bounds": {"top": 0, "left": 1254, "width": 304, "height": 38}},
+			{"name": "KQG.Section;prefix=infra_;pass=<=800;weight=2", "tileType": "HEADER", "bounds": {"top": 0, "left": 0, "width": 1254, "height": 38}},
+			{"name": "Host;sli=host;memory.pass=<=75", "tileType": "HOST", "bounds": {"top": 38, "left": 0, "width": 304, "height": 304}, "assignedEntities": ["HOST-1111111111111111"]}
+		]
+	}`), dashboardJSON)
+	if err != nil {
+		t.Fatalf("Couldnt parse dashboard: %v", err)
+	}
+
+	_, dashboardSLO, _ := dh.processDashboardTiles(dashboardJSON, time.Unix(1571649084, 0).UTC(), time.Unix(1571649085, 0).UTC())
+
+	// the mocked metrics query returns two hosts
+	if len(dashboardSLO.Objectives) != 4 {
+		t.Fatalf("Expected 4 SLOs but got %d", len(dashboardSLO.Objectives))
+	}
+	for _, objective := range dashboardSLO.Objectives {
+		expectedPass := "<=80"
+		if strings.HasPrefix(objective.SLI, "infra_host_memory") {
+			expectedPass = "<=75"
+		}
+		if !strings.HasPrefix(objective.SLI, "infra_host_") || len(objective.Pass) != 1 || objective.Pass[0].Criteria[0] != expectedPass || objective.Weight != 2 {
+			t.Errorf("Unexpected SLO for %s: pass %v, weight %d", objective.SLI, objective.Pass, objective.Weight)
+		}
+	}
+}
+
+func TestGetSLIDefinitionForSplitDimensions(t *testing.T) {
+	transaction := SplitDimension{Key: "transaction"}
+	service := SplitDimension{Key: "dt.entity.service", EntityDimension: true}
+	host := SplitDimension{Key: "dt.entity.host", EntityDimension: true}
+
+	tests := []struct {
+		name            string
+		splitDimensions []SplitDimension
+		dataEntry       MetricQueryResultNumbers
+		metricQuery     string
+		wantNames       []string
+		wantQuery       string
+	}{
+		{
+			name:            "single dimension from dimensionMap",
+			splitDimensions: []SplitDimension{transaction},
+			dataEntry:       MetricQueryResultNumbers{Dimensions: []string{"4 - Random Search"}, DimensionMap: map[string]string{"transaction": "4 - Random Search"}},
+			metricQuery:     "metricSelector=jmeter.usermetrics.transaction.meantime:avg:names&entitySelector=entityId(SERVICE-1)",
+			wantNames:       []string{"4 - Random Search"},
+			wantQuery:       `metricSelector=jmeter.usermetrics.transaction.meantime:avg:names:filter(eq(transaction,"4 - Random Search"))&entitySelector=entityId(SERVICE-1)`,
+		},
+		{
+			name:            "entity of the entitySelector type",
+			splitDimensions: []SplitDimension{service},
+			dataEntry:       MetricQueryResultNumbers{Dimensions: []string{"carts", "SERVICE-1"}},
+			metricQuery:     "metricSelector=builtin:service.response.time:avg:names&entitySelector=type(SERVICE)",
+			wantNames:       []string{"carts"},
+			wantQuery:       "metricSelector=builtin:service.response.time:avg:names&entitySelector=type(SERVICE),entityId(SERVICE-1)",
+		},
+		{
+			name:            "entity and dimension from dimensionMap",
+			splitDimensions: []SplitDimension{service, transaction},
+			dataEntry:       MetricQueryResultNumbers{DimensionMap: map[string]string{"dt.entity.service": "SERVICE-1", "dt.entity.service.name": "carts", "transaction": "login"}},
+			metricQuery:     "metricSelector=jmeter.usermetrics.transaction.meantime:avg:names",
+			wantNames:       []string{"carts", "login"},
+			wantQuery:       "metricSelector=jmeter.usermetrics.transaction.meantime:avg:names:filter(eq(transaction,login))&entitySelector=entityId(SERVICE-1)",
+		},
+		{
+			name:            "multiple entities and dimension from dimensions",
+			splitDimensions: []SplitDimension{host, service, transaction},
+			dataEntry:       MetricQueryResultNumbers{Dimensions: []string{"host1", "HOST-1", "carts", "SERVICE-1", "login"}},
+			metricQuery:     "metricSelector=custom.metric:avg:names&entitySelector=type(SERVICE)",
+			wantNames:       []string{"host1", "carts", "login"},
+			wantQuery:       "metricSelector=custom.metric:avg:names:filter(and(eq(dt.entity.host,HOST-1),eq(transaction,login)))&entitySelector=type(SERVICE),entityId(SERVICE-1)",
+		},
+		{
+			name:            "entity IDs without names",
+			splitDimensions: []SplitDimension{host, transaction},
+			dataEntry:       MetricQueryResultNumbers{Dimensions: []string{"HOST-1", "login"}},
+			metricQuery:     "metricSelector=custom.metric:avg:names&entitySelector=type(SERVICE)",
+			wantNames:       []string{"HOST-1", "login"},
+			wantQuery:       "metricSelector=custom.metric:avg:names:filter(and(eq(dt.entity.host,HOST-1),eq(transaction,login)))&entitySelector=type(SERVICE)",
+		},
+		{
+			name:            "entitySelector followed by other parameters",
+			splitDimensions: []SplitDimension{service, transaction},
+			dataEntry:       MetricQueryResultNumbers{Dimensions: []string{"carts", "SERVICE-1", "login"}},
+			metricQuery:     "metricSelector=builtin:service.response.time:avg:names&entitySelector=type(SERVICE)&resolution=1h&mzSelector=mzId(123)",
+			wantNames:       []string{"carts", "login"},
+			wantQuery:       "metricSelector=builtin:service.response.time:avg:names:filter(eq(transaction,login))&entitySelector=type(SERVICE),entityId(SERVICE-1)&resolution=1h&mzSelector=mzId(123)",
+		},
+		{
+			name:            "entity added to a query without entitySelector",
+			splitDimensions: []SplitDimension{service},
+			dataEntry:       MetricQueryResultNumbers{Dimensions: []string{"carts", "SERVICE-1"}},
+			metricQuery:     "metricSelector=builtin:service.response.time:avg:names&resolution=1h&mzSelector=mzId(123)",
+			wantNames:       []string{"carts"},
+			wantQuery:       "metricSelector=builtin:service.response.time:avg:names&resolution=1h&mzSelector=mzId(123)&entitySelector=entityId(SERVICE-1)",
+		},
+		{
+			name:            "entitySelector with quoted type and other criteria",
+			splitDimensions: []SplitDimension{service},
+			dataEntry:       MetricQueryResultNumbers{Dimensions: []string{"carts", "SERVICE-1"}},
+			metricQuery:     `metricSelector=builtin:service.response.time:avg:names&entitySelector=tag("app:a,b"),type("SERVICE"),mzName("my zone")&resolution=1h`,
+			wantNames:       []string{"carts"},
+			wantQuery:       `metricSelector=builtin:service.response.time:avg:names&entitySelector=tag("app:a,b"),type("SERVICE"),mzName("my zone"),entityId(SERVICE-1)&resolution=1h`,
+		},
+		{
+			name:            "entitySelector with entity IDs",
+			splitDimensions: []SplitDimension{host},
+			dataEntry:       MetricQueryResultNumbers{Dimensions: []string{"host1", "HOST-1"}},
+			metricQuery:     "metricSelector=builtin:host.cpu.usage:splitBy(\"dt.entity.host\"):avg:names&entitySelector=entityId(HOST-1,HOST-2)&mzSelector=mzId(123)",
+			wantNames:       []string{"host1"},
+			wantQuery:       "metricSelector=builtin:host.cpu.usage:splitBy(\"dt.entity.host\"):avg:names&entitySelector=entityId(HOST-1)&mzSelector=mzId(123)",
+		},
+		{
+			name:            "filter values are escaped",
+			splitDimensions: []SplitDimension{transaction},
+			dataEntry:       MetricQueryResultNumbers{Dimensions: []string{"a+b&c"}},
+			metricQuery:     "metricSelector=custom.metric:avg:names&resolution=1h",
+			wantNames:       []string{"a+b&c"},
+			wantQuery:       "metricSelector=custom.metric:avg:names:filter(eq(transaction,a%2Bb%26c))&resolution=1h",
+		},
+		{
+			name:            "dimensions dont match",
+			splitDimensions: []SplitDimension{transaction},
+			dataEntry:       MetricQueryResultNumbers{Dimensions: []string{"a", "b", "c"}},
+			metricQuery:     "metricSelector=custom.metric:avg:names",
+			wantNames:       []string{"a", "b", "c"},
+			wantQuery:       "metricSelector=custom.metric:avg:names",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			gotNames, gotQuery := getSLIDefinitionForSplitDimensions(tt.splitDimensions, tt.dataEntry, tt.metricQuery)
+			if strings.Join(gotNames, "|") != strings.Join(tt.wantNames, "|") {
+				t.Errorf("getSLIDefinitionForSplitDimensions() names = %v, want %v", gotNames, tt.wantNames)
+			}
+			if gotQuery != tt.wantQuery {
+				t.Errorf("getSLIDefinitionForSplitDimensions() query = %v, want %v", gotQuery, tt.wantQuery)
+			}
+		})
+	}
+}
+
 func TestQueryDynatraceDashboardForSLIs(t *testing.T) {
 	keptnEvent := testingGetKeptnEvent(QUALITYGATE_PROJECT, QUALITYGATE_STAGE, QUALTIYGATE_SERVICE, "", "")
 	dh, _, _, teardown := testingGetDynatraceHandler(keptnEvent)
@@ -358,6 +989,310 @@ func TestQueryDynatraceDashboardForSLIs(t *testing.T) {
 	}
 }
 
+func TestDashboardParseReport(t *testing.T) {
+	keptnEvent := testingGetKeptnEvent(QUALITYGATE_PROJECT, QUALITYGATE_STAGE, QUALTIYGATE_SERVICE, "", "")
+	dh, _, _, teardown := testingGetDynatraceHandler(keptnEvent)
+	defer teardown()
+
+	startTime := time.Unix(1571649084, 0).UTC()
+	endTime := time.Unix(1571649085, 0).UTC()
+	_, dashboardJSON, dashboardSLI, _, sliResults, err := dh.QueryDynatraceDashboardForSLIs(keptnEvent, common.DynatraceConfigDashboardQUERY, startTime, endTime)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(dh.DashboardParseReports) != 1 {
+		t.Fatalf("Expected 1 parse report but got %d", len(dh.DashboardParseReports))
+	}
+	parseReport := dh.DashboardParseReports[0]
+	if parseReport.DashboardID != dashboardJSON.ID || len(parseReport.Tiles) != len(dashboardJSON.Tiles) {
+		t.Fatalf("Expected a report for all %d tiles of dashboard %s but got %d tiles of %s", len(dashboardJSON.Tiles), dashboardJSON.ID, len(parseReport.Tiles), parseReport.DashboardID)
+	}
+
+	// every SLI belongs to exactly one included tile & every tile that didnt become an SLI says why
+	indicatorCount := 0
+	for _, tileReport := range parseReport.Tiles {
+		switch tileReport.Decision {
+		case TileDecisionIncluded:
+			indicatorCount += len(tileReport.Indicators)
+			for _, indicator := range tileReport.Indicators {
+				if _, found := dashboardSLI.Indicators[indicator]; !found {
+					t.Errorf("Tile %d reports unknown indicator %s", tileReport.Index, indicator)
+				}
+			}
+			if len(tileReport.Queries) == 0 || tileReport.Reason != "" {
+				t.Errorf("Included tile %d should have queries and no reason: %+v", tileReport.Index, tileReport)
+			}
+		case TileDecisionSkipped:
+			if tileReport.Reason == "" || len(tileReport.Indicators) > 0 {
+				t.Errorf("Skipped tile %d should have a reason and no indicators: %+v", tileReport.Index, tileReport)
+			}
+		default:
+			t.Errorf("Unexpected decision for tile %d: %+v", tileReport.Index, tileReport)
+		}
+	}
+	if indicatorCount != len(sliResults) {
+		t.Errorf("Expected %d indicators in the report but got %d", len(sliResults), indicatorCount)
+	}
+
+	if hostHealth := parseReport.Tiles[23]; hostHealth.TileType != "HOSTS" || hostHealth.Reason != "title doesnt include sli=SLINAME" {
+		t.Errorf("Unexpected report for the host health tile: %+v", hostHealth)
+	}
+}
+
+func TestTileParseReportAddSLIResults(t *testing.T) {
+	dashboardSLI := &SLI{Indicators: map[string]string{
+		"rt_carts":  "MV2;MicroSecond;metricSelector=builtin:service.response.time:names",
+		"rt_orders": "MV2;MicroSecond;metricSelector=builtin:service.response.time:names",
+	}}
+	tileReport := &TileParseReport{TileType: "CUSTOM_CHARTING", Decision: TileDecisionSkipped}
+	tileReport.addSLIResults([]*keptnv2.SLIResult{
+		{Metric: "rt_carts", Value: 10, Success: true},
+		{Metric: "rt_orders", Success: false, Message: "No result for query"},
+		{Metric: "rt_payment", Success: false, Message: "No result for query"},
+	}, dashboardSLI)
+
+	expected := &TileParseReport{
+		TileType:   "CUSTOM_CHARTING",
+		Decision:   TileDecisionFailed,
+		Reason:     "No result for query",
+		Queries:    []string{"MV2;MicroSecond;metricSelector=builtin:service.response.time:names"},
+		Indicators: []string{"rt_carts", "rt_orders", "rt_payment"},
+	}
+	if !reflect.DeepEqual(tileReport, expected) {
+		t.Errorf("addSLIResults() = %+v, want %+v", tileReport, expected)
+	}
+}
+
+func TestQueryDynatraceDashboardForSLIsFromRepo(t *testing.T) {
+	keptnEvent := testingGetKeptnEvent(QUALITYGATE_PROJECT, QUALITYGATE_STAGE, QUALTIYGATE_SERVICE, "", "")
+	dh, _, _, teardown := testingGetDynatraceHandler(keptnEvent)
+	defer teardown()
+
+	// the dashboard in the repo has an ID the tenant doesnt know - so - it can only be parsed from the repo
+	dashboardContent, err := ioutil.ReadFile("./testfiles/test_get_dashboards_id.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	dashboardContent = bytes.Replace(dashboardContent, []byte(QUALITYGATE_DASHBOARD_ID), []byte("87654321-1111-4444-8888-123456789012"), -1)
+
+	repoDirectory := t.TempDir()
+	os.MkdirAll(repoDirectory+"/dynatrace", 0755)
+	ioutil.WriteFile(repoDirectory+"/"+common.DynatraceDashboardFilename, dashboardContent, 0644)
+	testingSetResourceStore(t, common.NewLocalResourceStore(repoDirectory))
+
+	startTime := time.Unix(1571649084, 0).UTC()
+	endTime := time.Unix(1571649085, 0).UTC()
+	dashboardLinkAsLabel, dashboardJSON, dashboardSLI, dashboardSLO, sliResults, err := dh.QueryDynatraceDashboardForSLIs(keptnEvent, common.DynatraceConfigDashboardREPO, startTime, endTime)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !strings.Contains(dashboardLinkAsLabel, "87654321-1111-4444-8888-123456789012") {
+		t.Errorf("Dashboard link label doesnt link to the dashboard from the repo: %s", dashboardLinkAsLabel)
+	}
+
+	if dashboardJSON == nil || dashboardSLI == nil || dashboardSLO == nil {
+		t.Fatalf("No Dashboard, SLI or SLO returned")
+	}
+
+	expectedSLOs := 14
+	if len(dashboardSLI.Indicators) != expectedSLOs || len(dashboardSLO.Objectives) != expectedSLOs || len(sliResults) != expectedSLOs {
+		t.Errorf("Excepted %d SLIs, SLOs and SLI Results but got %d, %d, %d", expectedSLOs, len(dashboardSLI.Indicators), len(dashboardSLO.Objectives), len(sliResults))
+	}
+
+	// without a dashboard in the repo we expect an error
+	testingSetResourceStore(t, common.NewLocalResourceStore(t.TempDir()))
+	_, dashboardJSON, _, _, _, err = dh.QueryDynatraceDashboardForSLIs(keptnEvent, common.DynatraceConfigDashboardREPO, startTime, endTime)
+	if err == nil || dashboardJSON != nil {
+		t.Errorf("Expected an error for dashboard: repo without a dashboard in the repo")
+	}
+}
+
+func TestHasDashboardChanged(t *testing.T) {
+	keptnEvent := testingGetKeptnEvent(QUALITYGATE_PROJECT, QUALITYGATE_STAGE, QUALTIYGATE_SERVICE, "", "")
+	dh, _, _, teardown := testingGetDynatraceHandler(keptnEvent)
+	defer teardown()
+
+	dashboardContent, err := ioutil.ReadFile("./testfiles/test_get_dashboards_id.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	loadDashboard := func() *DynatraceDashboard {
+		dashboardJSON := &DynatraceDashboard{}
+		if err := json.Unmarshal(dashboardContent, dashboardJSON); err != nil {
+			t.Fatal(err)
+		}
+		return dashboardJSON
+	}
+
+	repoDirectory := t.TempDir()
+	serviceDirectory := repoDirectory + "/" + QUALITYGATE_STAGE + "/" + QUALTIYGATE_SERVICE
+	os.MkdirAll(serviceDirectory+"/dynatrace", 0755)
+	testingSetResourceStore(t, common.NewLocalResourceStore(repoDirectory))
+
+	if !dh.HasDashboardChanged(keptnEvent, loadDashboard()) {
+		t.Errorf("Expected a change without an sli.yaml")
+	}
+
+	// the sli.yaml was generated from this dashboard version - but there is no slo.yaml
+	ioutil.WriteFile(serviceDirectory+"/"+common.DynatraceSLIFilename, []byte("spec_version: 0.1.4\ndashboard_version: "+getDashboardVersion(loadDashboard())+"\nindicators:\n  rt: MV2;MicroSecond;metricSelector=builtin:service.response.time\n"), 0644)
+	if !dh.HasDashboardChanged(keptnEvent, loadDashboard()) {
+		t.Errorf("Expected a change without an slo.yaml")
+	}
+
+	ioutil.WriteFile(serviceDirectory+"/"+common.KeptnSLOFilename, []byte("spec_version: '0.1.0'\nobjectives:\n- sli: rt\n"), 0644)
+	if dh.HasDashboardChanged(keptnEvent, loadDashboard()) {
+		t.Errorf("Expected no change for the same dashboard version")
+	}
+
+	// updating Dynatrace doesnt change the dashboard
+	dashboardJSON := loadDashboard()
+	dashboardJSON.Metadata.ClusterVersion = "1.999.0"
+	if dh.HasDashboardChanged(keptnEvent, dashboardJSON) {
+		t.Errorf("Expected no change for a different cluster version")
+	}
+
+	dashboardJSON = loadDashboard()
+	dashboardJSON.Tiles[0].Name = dashboardJSON.Tiles[0].Name + ";weight=2"
+	if !dh.HasDashboardChanged(keptnEvent, dashboardJSON) {
+		t.Errorf("Expected a change for a changed tile")
+	}
+
+	dashboardJSON = loadDashboard()
+	dashboardJSON.Metadata.ConfigurationVersions = append(dashboardJSON.Metadata.ConfigurationVersions, 99)
+	if !dh.HasDashboardChanged(keptnEvent, dashboardJSON) {
+		t.Errorf("Expected a change for different configuration versions")
+	}
+
+	// KQG.QueryBehavior=Always parses the dashboard on every evaluation
+	dashboardJSON = loadDashboard()
+	dashboardJSON.DashboardMetadata.Tags = append(dashboardJSON.DashboardMetadata.Tags, "kqg.querybehavior:Always")
+	ioutil.WriteFile(serviceDirectory+"/"+common.DynatraceSLIFilename, []byte("spec_version: 0.1.4\ndashboard_version: "+getDashboardVersion(dashboardJSON)+"\nindicators:\n  rt: MV2;MicroSecond;metricSelector=builtin:service.response.time\n"), 0644)
+	if !dh.HasDashboardChanged(keptnEvent, dashboardJSON) {
+		t.Errorf("Expected a change for KQG.QueryBehavior=Always")
+	}
+}
+
+func TestQueryDynatraceDashboardForSLIsReusesUnchangedDashboard(t *testing.T) {
+	keptnEvent := testingGetKeptnEvent(QUALITYGATE_PROJECT, QUALITYGATE_STAGE, QUALTIYGATE_SERVICE, "", "")
+	dh, _, _, teardown := testingGetDynatraceHandler(keptnEvent)
+	defer teardown()
+
+	repoDirectory := t.TempDir()
+	testingSetResourceStore(t, common.NewLocalResourceStore(repoDirectory))
+
+	startTime := time.Unix(1571649084, 0).UTC()
+	endTime := time.Unix(1571649085, 0).UTC()
+	_, dashboardJSON, dashboardSLI, dashboardSLO, sliResults, err := dh.QueryDynatraceDashboardForSLIs(keptnEvent, QUALITYGATE_DASHBOARD_ID, startTime, endTime)
+	if err != nil || dashboardJSON == nil || sliResults == nil || !dh.DashboardReparsed {
+		t.Fatalf("Expected the dashboard to be parsed in the first evaluation: %v", err)
+	}
+	if dashboardSLI.DashboardVersion != getDashboardVersion(dashboardJSON) {
+		t.Errorf("Expected sli.yaml to record dashboard version %s but got %s", getDashboardVersion(dashboardJSON), dashboardSLI.DashboardVersion)
+	}
+
+	// store the generated files like the service does after parsing
+	sliContent, _ := yaml.Marshal(dashboardSLI)
+	sloContent, _ := yaml.Marshal(dashboardSLO)
+	common.UploadKeptnResource(sliContent, common.DynatraceSLIFilename, keptnEvent)
+	common.UploadKeptnResource(sloContent, common.KeptnSLOFilename, keptnEvent)
+
+	dh, _, _, teardown = testingGetDynatraceHandler(keptnEvent)
+	defer teardown()
+	dashboardLinkAsLabel, dashboardJSON, _, _, sliResults, err := dh.QueryDynatraceDashboardForSLIs(keptnEvent, QUALITYGATE_DASHBOARD_ID, startTime, endTime)
+	if err != nil || dashboardJSON != nil || sliResults != nil || dh.DashboardReparsed {
+		t.Errorf("Expected the sli.yaml & slo.yaml of the unchanged dashboard to be reused: %v", err)
+	}
+	if dashboardLinkAsLabel == "" {
+		t.Errorf("Expected a dashboard link for a reused dashboard")
+	}
+}
+
+func TestQueryDynatraceDashboardsForSLIs(t *testing.T) {
+	keptnEvent := testingGetKeptnEvent(QUALITYGATE_PROJECT, QUALITYGATE_STAGE, QUALTIYGATE_SERVICE, "", "")
+	dh, _, _, teardown := testingGetDynatraceHandler(keptnEvent)
+	defer teardown()
+
+	dashboardContent, err := ioutil.ReadFile("./testfiles/test_get_dashboards_id.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// lets put the same dashboard into the repo - once with the same and once with a different KQG.Total.Pass
+	sameTotalRepoDirectory := t.TempDir()
+	os.MkdirAll(sameTotalRepoDirectory+"/dynatrace", 0755)
+	ioutil.WriteFile(sameTotalRepoDirectory+"/"+common.DynatraceDashboardFilename, dashboardContent, 0644)
+
+	otherTotalRepoDirectory := t.TempDir()
+	os.MkdirAll(otherTotalRepoDirectory+"/dynatrace", 0755)
+	ioutil.WriteFile(otherTotalRepoDirectory+"/"+common.DynatraceDashboardFilename, bytes.Replace(dashboardContent, []byte("KQG.Total.Pass=90%"), []byte("KQG.Total.Pass=80%"), 1), 0644)
+
+	startTime := time.Unix(1571649084, 0).UTC()
+	endTime := time.Unix(1571649085, 0).UTC()
+
+	tests := []struct {
+		name          string
+		repoDirectory string
+		dashboards    []common.DynatraceConfigDashboard
+		wantSLIs      int
+		wantErr       string
+	}{
+		{
+			name:          "two dashboards with prefixes",
+			repoDirectory: sameTotalRepoDirectory,
+			dashboards:    []common.DynatraceConfigDashboard{{Dashboard: QUALITYGATE_DASHBOARD_ID, Prefix: "team_"}, {Dashboard: common.DynatraceConfigDashboardREPO, Prefix: "platform_"}},
+			wantSLIs:      28,
+		},
+		{
+			name:          "duplicate indicator names",
+			repoDirectory: sameTotalRepoDirectory,
+			dashboards:    []common.DynatraceConfigDashboard{{Dashboard: QUALITYGATE_DASHBOARD_ID}, {Dashboard: common.DynatraceConfigDashboardREPO}},
+			wantErr:       "is defined by multiple dashboards",
+		},
+		{
+			name:          "conflicting KQG.Total.Pass",
+			repoDirectory: otherTotalRepoDirectory,
+			dashboards:    []common.DynatraceConfigDashboard{{Dashboard: QUALITYGATE_DASHBOARD_ID, Prefix: "team_"}, {Dashboard: common.DynatraceConfigDashboardREPO, Prefix: "platform_"}},
+			wantErr:       "KQG.Total.Pass=80% conflicts with KQG.Total.Pass=90%",
+		},
+		{
+			name:          "dashboard that doesnt exist",
+			repoDirectory: t.TempDir(),
+			dashboards:    []common.DynatraceConfigDashboard{{Dashboard: QUALITYGATE_DASHBOARD_ID, Prefix: "team_"}, {Dashboard: common.DynatraceConfigDashboardREPO, Prefix: "platform_"}},
+			wantErr:       "dashboard 'repo'",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			testingSetResourceStore(t, common.NewLocalResourceStore(tt.repoDirectory))
+
+			_, dashboardSLI, dashboardSLO, sliResults, err := dh.QueryDynatraceDashboardsForSLIs(keptnEvent, tt.dashboards, startTime, endTime)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Errorf("QueryDynatraceDashboardsForSLIs() error = %v, want %s", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if len(dashboardSLI.Indicators) != tt.wantSLIs || len(dashboardSLO.Objectives) != tt.wantSLIs || len(sliResults) != tt.wantSLIs {
+				t.Errorf("Excepted %d SLIs, SLOs and SLI Results but got %d, %d, %d", tt.wantSLIs, len(dashboardSLI.Indicators), len(dashboardSLO.Objectives), len(sliResults))
+			}
+			for _, sliResult := range sliResults {
+				if _, found := dashboardSLI.Indicators[sliResult.Metric]; !found {
+					t.Errorf("SLI Result %s has no SLI definition", sliResult.Metric)
+				}
+			}
+			if dashboardSLO.TotalScore.Pass != "90%" || dashboardSLO.TotalScore.Warning != "70%" {
+				t.Errorf("Total Warning and Pass Scores not as expected. Got %s (pass) and %s (warning)", dashboardSLO.TotalScore.Pass, dashboardSLO.TotalScore.Warning)
+			}
+		})
+	}
+}
+
 func TestExecuteGetDynatraceSLO(t *testing.T) {
 	keptnEvent := testingGetKeptnEvent(QUALITYGATE_PROJECT, QUALITYGATE_STAGE, QUALTIYGATE_SERVICE, "", "")
 	dh, _, _, teardown := testingGetDynatraceHandler(keptnEvent)
@@ -379,24 +1314,222 @@ func TestExecuteGetDynatraceSLO(t *testing.T) {
 	if sloResult.EvaluatedPercentage != 95.66405076939219 {
 		t.Error("Not returning expected value for SLO")
 	}
-}
-
-func TestGetSLIValueWithSLOPrefix(t *testing.T) {
-
-	keptnEvent := testingGetKeptnEvent(QUALITYGATE_PROJECT, QUALITYGATE_STAGE, QUALTIYGATE_SERVICE, "", "")
-	dh, _, _, teardown := testingGetDynatraceHandler(keptnEvent)
-	defer teardown()
-
-	dh.CustomQueries = make(map[string]string)
-	dh.CustomQueries["RT_faster_500ms"] = "SLO;524ca177-849b-3e8c-8175-42b93fbc33c5"
-
-	startTime := time.Unix(1571649084, 0).UTC()
-	endTime := time.Unix(1571649085, 0).UTC()
-
-	_, err := dh.GetSLIValue("RT_faster_500ms", startTime, endTime)
-
-	if err != nil {
-		t.Error(err)
+}
+
+func TestGetSLIValueWithSLOPrefix(t *testing.T) {
+
+	keptnEvent := testingGetKeptnEvent(QUALITYGATE_PROJECT, QUALITYGATE_STAGE, QUALTIYGATE_SERVICE, "", "")
+	dh, _, _, teardown := testingGetDynatraceHandler(keptnEvent)
+	defer teardown()
+
+	dh.CustomQueries = make(map[string]string)
+	dh.CustomQueries["RT_faster_500ms"] = "SLO;524ca177-849b-3e8c-8175-42b93fbc33c5"
+
+	startTime := time.Unix(1571649084, 0).UTC()
+	endTime := time.Unix(1571649085, 0).UTC()
+
+	_, err := dh.GetSLIValue("RT_faster_500ms", startTime, endTime)
+
+	if err != nil {
+		t.Error(err)
+	}
+}
+
+func TestGetSLIValueWithUSQLPrefix(t *testing.T) {
+	keptnEvent := testingGetKeptnEvent(QUALITYGATE_PROJECT, QUALITYGATE_STAGE, QUALTIYGATE_SERVICE, "", "")
+	dh, _, _, teardown := testingGetDynatraceHandler(keptnEvent)
+	defer teardown()
+
+	dh.CustomQueries = make(map[string]string)
+	dh.CustomQueries["sessions"] = "USQL;SINGLE_VALUE,value=count(*);;SELECT city, count(*) FROM usersession GROUP BY city"
+	dh.CustomQueries["duration_linz"] = "USQL;TABLE;Linz;SELECT city, count(*), avg(duration), max(duration) FROM usersession GROUP BY city"
+	dh.CustomQueries["duration_avg_linz"] = "USQL;TABLE,dimension=city,value=avg(duration);Linz;SELECT city, count(*), avg(duration), max(duration) FROM usersession WHERE city <> \"Graz;Austria\" GROUP BY city"
+	dh.CustomQueries["duration_avg_vienna"] = "USQL;TABLE,value=2;Vienna;SELECT city, count(*), avg(duration), max(duration) FROM usersession GROUP BY city"
+	dh.CustomQueries["duration_unknown_column"] = "USQL;TABLE,value=min(duration);Linz;SELECT city, count(*), avg(duration), max(duration) FROM usersession GROUP BY city"
+	dh.CustomQueries["sessions_shorthand"] = "USQL;Count(*);SELECT city, count(*) FROM usersession GROUP BY city"
+	dh.CustomQueries["duration_shorthand"] = "USQL;2;SELECT city, count(*), avg(duration) FROM usersession GROUP BY city"
+
+	startTime := time.Unix(1571649084, 0).UTC()
+	endTime := time.Unix(1571649085, 0).UTC()
+
+	tests := []struct {
+		metric    string
+		want      float64
+		wantError bool
+	}{
+		{metric: "sessions", want: 12},
+		{metric: "duration_linz", want: 2000},
+		{metric: "duration_avg_linz", want: 1500.5},
+		{metric: "duration_avg_vienna", wantError: true},
+		{metric: "duration_unknown_column", wantError: true},
+		{metric: "sessions_shorthand", want: 12},
+		{metric: "duration_shorthand", want: 1500.5},
+	}
+	for _, tt := range tests {
+		t.Run(tt.metric, func(t *testing.T) {
+			value, err := dh.GetSLIValue(tt.metric, startTime, endTime)
+			if tt.wantError {
+				if err == nil {
+					t.Errorf("GetSLIValue() should fail but returned %v", value)
+				}
+				return
+			}
+			if err != nil || value != tt.want {
+				t.Errorf("GetSLIValue() = %v, %v - want %v", value, err, tt.want)
+			}
+		})
+	}
+}
+
+func TestDecodeUSQLResult(t *testing.T) {
+	result := &DTUSQLResult{
+		ColumnNames: []string{"time", "city", "count(*)", "avg(duration)"},
+		Values: [][]interface{}{
+			{json.Number("1571649000000"), "Linz", json.Number("10"), 100.0},
+			{json.Number("1571649060000"), "Linz", "20", nil},
+			{json.Number("1571649000000"), nil, true, []interface{}{"a", "b"}},
+			{json.Number("1571649000000"), []interface{}{"Linz", 2.0}, "n/a", 5.0},
+		},
+	}
+
+	tests := []struct {
+		name            string
+		tileType        string
+		dimensionColumn string
+		valueColumns    []string
+		want            []string
+		wantError       bool
+	}{
+		{
+			name:     "line chart averages per dimension and skips nulls",
+			tileType: USQLTileTypeLineChart,
+			want:     []string{"Linz/avg(duration)=100", "null/avg(duration)=error", "Linz,2/avg(duration)=5"},
+		},
+		{
+			name:            "table with multiple value columns",
+			tileType:        USQLTileTypeTable,
+			dimensionColumn: "1",
+			valueColumns:    []string{"count(*)", "3"},
+			want:            []string{"Linz/count(*)=10", "Linz/avg(duration)=100", "Linz/count(*)=20", "Linz/avg(duration)=error", "null/count(*)=1", "null/avg(duration)=error", "Linz,2/count(*)=error", "Linz,2/avg(duration)=5"},
+		},
+		{
+			name:            "all value columns of a pie chart",
+			tileType:        USQLTileTypePieChart,
+			dimensionColumn: "CITY",
+			valueColumns:    []string{USQLAllValueColumns},
+			want:            []string{"Linz/time=1571649000000", "Linz/count(*)=10", "Linz/avg(duration)=100", "Linz/time=1571649060000", "Linz/count(*)=20", "Linz/avg(duration)=error", "null/time=1571649000000", "null/count(*)=1", "null/avg(duration)=error", "Linz,2/time=1571649000000", "Linz,2/count(*)=error", "Linz,2/avg(duration)=5"},
+		},
+		{
+			name:         "single value only takes the first row",
+			tileType:     USQLTileTypeSingleValue,
+			valueColumns: []string{"2"},
+			want:         []string{"/count(*)=10"},
+		},
+		{
+			name:         "unknown column",
+			tileType:     USQLTileTypeTable,
+			valueColumns: []string{"max(duration)"},
+			wantError:    true,
+		},
+		{
+			name:         "column index out of range",
+			tileType:     USQLTileTypeTable,
+			valueColumns: []string{"4"},
+			wantError:    true,
+		},
+		{
+			name:      "unsupported tile type",
+			tileType:  "FUNNEL",
+			wantError: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			values, err := DecodeUSQLResult(result, tt.tileType, tt.dimensionColumn, tt.valueColumns)
+			if tt.wantError {
+				if err == nil {
+					t.Errorf("DecodeUSQLResult() should fail")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			var got []string
+			for _, value := range values {
+				if value.Err != nil {
+					got = append(got, fmt.Sprintf("%s/%s=error", value.Dimension, value.Column))
+				} else {
+					got = append(got, fmt.Sprintf("%s/%s=%s", value.Dimension, value.Column, strconv.FormatFloat(value.Value, 'f', -1, 64)))
+				}
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("DecodeUSQLResult() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseUSQLSLIDefinition(t *testing.T) {
+	tests := []struct {
+		definition string
+		want       *usqlSLIDefinition
+		wantError  bool
+	}{
+		{
+			definition: "MV2;Percent;metricSelector=builtin:service.errors.total.rate:merge(0):avg",
+			want:       nil,
+		},
+		{
+			definition: "select count(internalUserId) as \"Session Count\" FROM usersession where bounce = true",
+			want:       &usqlSLIDefinition{TileType: USQLTileTypeSingleValue, Query: "select count(internalUserId) as \"Session Count\" FROM usersession where bounce = true"},
+		},
+		{
+			definition: "SELECT count(*) FROM useraction",
+			want:       &usqlSLIDefinition{TileType: USQLTileTypeSingleValue, Query: "SELECT count(*) FROM useraction"},
+		},
+		{
+			definition: "USQL;SELECT count(*) FROM usererror",
+			want:       &usqlSLIDefinition{TileType: USQLTileTypeSingleValue, Query: "SELECT count(*) FROM usererror"},
+		},
+		{
+			definition: "USQL;Session Count;select count(internalUserId) as \"Session Count\", avg(duration) FROM usersession where name = \"a;b\"",
+			want:       &usqlSLIDefinition{TileType: USQLTileTypeSingleValue, ValueColumns: []string{"Session Count"}, Query: "select count(internalUserId) as \"Session Count\", avg(duration) FROM usersession where name = \"a;b\""},
+		},
+		{
+			definition: "USQL;TABLE;Linz;SELECT city, count(*) FROM usersession GROUP BY city",
+			want:       &usqlSLIDefinition{TileType: USQLTileTypeTable, Dimension: "Linz", Query: "SELECT city, count(*) FROM usersession GROUP BY city"},
+		},
+		{
+			definition: "USQL;TABLE,dimension=0,value=1;Linz;SELECT city, count(*) FROM usersession GROUP BY city",
+			want:       &usqlSLIDefinition{TileType: USQLTileTypeTable, DimensionColumn: "0", ValueColumns: []string{"1"}, Dimension: "Linz", Query: "SELECT city, count(*) FROM usersession GROUP BY city"},
+		},
+		{
+			definition: "USQL;FUNNEL;;SELECT FUNNEL(useraction.name = \"a\" AS \"A\") FROM usersession",
+			want:       &usqlSLIDefinition{TileType: "FUNNEL", Query: "SELECT FUNNEL(useraction.name = \"a\" AS \"A\") FROM usersession"},
+		},
+		{
+			definition: "USQL;count(*)",
+			wantError:  true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.definition, func(t *testing.T) {
+			got, err := parseUSQLSLIDefinition(tt.definition)
+			if tt.wantError {
+				if err == nil {
+					t.Errorf("parseUSQLSLIDefinition() should fail but returned %v", got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("parseUSQLSLIDefinition() = %v, want %v", got, tt.want)
+			}
+		})
 	}
 }
 
@@ -404,7 +1537,7 @@ func TestGetCustomQueries(t *testing.T) {
 	keptnEvent := testingGetKeptnEvent(QUALITYGATE_PROJECT, QUALITYGATE_STAGE, QUALTIYGATE_SERVICE, "", "")
 	keptncommon.NewLogger("test-context", "test-event", "dynatrace-sli-service-testing")
 
-	common.RunLocal = true
+	testingSetResourceStore(t, common.NewLocalResourceStore("../../../"))
 
 	customQueries, err := common.GetCustomQueries(keptnEvent)
 
@@ -412,6 +1545,10 @@ func TestGetCustomQueries(t *testing.T) {
 		t.Error(err)
 	}
 
+	if len(customQueries) == 0 {
+		t.Errorf("No custom queries loaded from dynatrace/sli.yaml")
+	}
+
 	for k, v := range customQueries {
 		fmt.Printf("%s: %s\n", k, v)
 	}
@@ -664,6 +1801,42 @@ func TestGetTimeseriesUnsupportedSLI(t *testing.T) {
 	}
 }
 
+// Test that the built-in SLIs use the configured entitySelector template or preset
+func TestGetTimeseriesConfigWithEntitySelector(t *testing.T) {
+	keptnEvent := testingGetKeptnEvent("sockshop", "dev", "carts", "", "")
+	dh, _, _, teardown := testingGetDynatraceHandler(keptnEvent)
+	defer teardown()
+
+	tests := []struct {
+		entitySelector string
+		want           string
+	}{
+		{
+			entitySelector: "",
+			want:           "metricSelector=builtin:service.requestCount.total:merge(0):sum&entitySelector=type(SERVICE),tag(keptn_project:$PROJECT),tag(keptn_stage:$STAGE),tag(keptn_service:$SERVICE),tag(keptn_deployment:$DEPLOYMENT)",
+		},
+		{
+			entitySelector: common.DynatraceConfigEntitySelectorMZ,
+			want:           "metricSelector=builtin:service.requestCount.total:merge(0):sum&entitySelector=type(SERVICE),mzName(Keptn: $PROJECT $STAGE),entityName($SERVICE)",
+		},
+		{
+			entitySelector: "type(SERVICE),tag(app:$SERVICE)",
+			want:           "metricSelector=builtin:service.requestCount.total:merge(0):sum&entitySelector=type(SERVICE),tag(app:$SERVICE)",
+		},
+	}
+
+	for _, tt := range tests {
+		dh.EntitySelector = tt.entitySelector
+		got, err := dh.getTimeseriesConfig(Throughput)
+		if err != nil {
+			t.Error(err)
+		}
+		if got != tt.want {
+			t.Errorf("dh.getTimeseriesConfig() returned %s, expected %s", got, tt.want)
+		}
+	}
+}
+
 func TestTimestampToString(t *testing.T) {
 	dt := time.Now()
 
@@ -724,6 +1897,410 @@ func TestScaleData(t *testing.T) {
 	if scaleData("builtin:service.response.time", "", 1000000.0) != 1000.0 {
 		t.Errorf("scaleData incorrectly scales builtin:service.response.time")
 	}
+	if scaleData("", "Percent,ratio=other", 2.5) != 97.5 {
+		t.Errorf("scaleData incorrectly calculates the other ratio of a Percent")
+	}
+	if scaleData("", "Ratio,ratio=other", 0.25) != 0.75 {
+		t.Errorf("scaleData incorrectly calculates the other ratio")
+	}
+}
+
+func TestGenerateMetricQueryFromChartWithMultipleDimensionValues(t *testing.T) {
+	keptnEvent := testingGetKeptnEvent(QUALITYGATE_PROJECT, QUALITYGATE_STAGE, QUALTIYGATE_SERVICE, "", "")
+	dh, _, _, teardown := testingGetDynatraceHandler(keptnEvent)
+	defer teardown()
+
+	series := ChartSeries{}
+	err := json.Unmarshal([]byte(`{"metric":"builtin:service.errors.server.rate","aggregation":"OTHER_RATIO","entityType":"SERVICE","dimensions":[{"id":"0","name":"dt.entity.service","values":["SERVICE-1","SERVICE-2"],"entitiyDimension":true}]}`), &series)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, metricUnit, metricQuery, _, splitDimensions, err := dh.GenerateMetricQueryFromChart(series, "", nil, time.Unix(1571649084, 0).UTC(), time.Unix(1571649085, 0).UTC())
+	if err != nil {
+		t.Fatalf("GenerateMetricQueryFromChart() returned an error: %v", err)
+	}
+
+	expectedQuery := "metricSelector=builtin:service.errors.server.rate:filter(or(eq(dt.entity.service,SERVICE-1),eq(dt.entity.service,SERVICE-2))):avg:names&entitySelector=type(SERVICE)"
+	if metricQuery != expectedQuery {
+		t.Errorf("GenerateMetricQueryFromChart() query = %s, want %s", metricQuery, expectedQuery)
+	}
+	if metricUnit != "Percent,ratio=other" {
+		t.Errorf("GenerateMetricQueryFromChart() unit = %s, want Percent,ratio=other", metricUnit)
+	}
+	if len(splitDimensions) != 0 {
+		t.Errorf("GenerateMetricQueryFromChart() returned split dimensions for a filtered dimension: %v", splitDimensions)
+	}
+
+	series.Aggregation = "SUM"
+	if _, _, _, _, _, err := dh.GenerateMetricQueryFromChart(series, "", nil, time.Unix(1571649084, 0).UTC(), time.Unix(1571649085, 0).UTC()); err == nil {
+		t.Errorf("GenerateMetricQueryFromChart() should fail for an aggregation the metric doesnt support")
+	}
+}
+
+// The aggregation rate of a chart series is only encoded for aggregations that add up over the timeframe
+func TestGenerateMetricQueryFromChartWithAggregationRate(t *testing.T) {
+	keptnEvent := testingGetKeptnEvent(QUALITYGATE_PROJECT, QUALITYGATE_STAGE, QUALTIYGATE_SERVICE, "", "")
+	dh, _, _, teardown := testingGetDynatraceHandler(keptnEvent)
+	defer teardown()
+
+	tests := []struct {
+		name     string
+		series   string
+		wantUnit string
+	}{
+		{
+			name:     "count per minute",
+			series:   `{"metric":"builtin:service.requestCount.total","aggregation":"VALUE","aggregationRate":"MINUTE","entityType":"SERVICE"}`,
+			wantUnit: "Count,rate=minute",
+		},
+		{
+			name:     "avg ignores the rate",
+			series:   `{"metric":"builtin:service.response.time","aggregation":"AVG","aggregationRate":"MINUTE","entityType":"SERVICE"}`,
+			wantUnit: "MicroSecond",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			series := ChartSeries{}
+			if err := json.Unmarshal([]byte(tt.series), &series); err != nil {
+				t.Fatal(err)
+			}
+
+			_, metricUnit, _, _, _, err := dh.GenerateMetricQueryFromChart(series, "", nil, time.Unix(1571649084, 0).UTC(), time.Unix(1571649085, 0).UTC())
+			if err != nil {
+				t.Fatalf("GenerateMetricQueryFromChart() returned an error: %v", err)
+			}
+			if metricUnit != tt.wantUnit {
+				t.Errorf("GenerateMetricQueryFromChart() unit = %s, want %s", metricUnit, tt.wantUnit)
+			}
+		})
+	}
+}
+
+func TestNormalizeDataToRate(t *testing.T) {
+	startTime := time.Unix(1571649084, 0).UTC()
+	tests := []struct {
+		name            string
+		aggregationRate string
+		aggregation     string
+		unit            string
+		timeframe       time.Duration
+		value           float64
+		wantUnit        string
+		wantValue       float64
+		wantErr         bool
+	}{
+		{name: "total", aggregationRate: "TOTAL", aggregation: "count", unit: "Count", timeframe: 10 * time.Minute, value: 600, wantUnit: "Count", wantValue: 600},
+		{name: "per second", aggregationRate: "SECOND", aggregation: "count", unit: "Count", timeframe: 10 * time.Minute, value: 600, wantUnit: "Count,rate=second", wantValue: 1},
+		{name: "per minute", aggregationRate: "MINUTE", aggregation: "sum", unit: "Count", timeframe: 10 * time.Minute, value: 600, wantUnit: "Count,rate=minute", wantValue: 60},
+		{name: "per hour", aggregationRate: "HOUR", aggregation: "count", unit: "Count", timeframe: 30 * time.Minute, value: 600, wantUnit: "Count,rate=hour", wantValue: 1200},
+		{name: "value of a count", aggregationRate: "MINUTE", aggregation: "value", unit: "Count", timeframe: 10 * time.Minute, value: 600, wantUnit: "Count,rate=minute", wantValue: 60},
+		{name: "empty timeframe", aggregationRate: "MINUTE", aggregation: "count", unit: "Count", timeframe: 0, value: 600, wantUnit: "Count,rate=minute", wantValue: 600},
+		{name: "avg ignores the rate", aggregationRate: "MINUTE", aggregation: "avg", unit: "MicroSecond", timeframe: 10 * time.Minute, value: 600, wantUnit: "MicroSecond", wantValue: 600},
+		{name: "percentile ignores the rate", aggregationRate: "MINUTE", aggregation: "percentile(90.000000)", unit: "MicroSecond", timeframe: 10 * time.Minute, value: 600, wantUnit: "MicroSecond", wantValue: 600},
+		{name: "value of a gauge ignores the rate", aggregationRate: "MINUTE", aggregation: "value", unit: "Percent", timeframe: 10 * time.Minute, value: 60, wantUnit: "Percent", wantValue: 60},
+		{name: "unsupported rate", aggregationRate: "DAY", aggregation: "count", unit: "Count", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			gotUnit, err := getChartMetricUnitWithRate(tt.aggregationRate, tt.aggregation, tt.unit)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("getChartMetricUnitWithRate() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if tt.wantErr {
+				return
+			}
+			if gotUnit != tt.wantUnit {
+				t.Errorf("getChartMetricUnitWithRate() = %v, want %v", gotUnit, tt.wantUnit)
+			}
+			metricID := "builtin:service.requestCount.total:merge(0):" + tt.aggregation + ":names"
+			if gotValue := normalizeDataToRate(metricID, gotUnit, tt.value, startTime, startTime.Add(tt.timeframe)); gotValue != tt.wantValue {
+				t.Errorf("normalizeDataToRate() = %v, want %v", gotValue, tt.wantValue)
+			}
+		})
+	}
+
+	// a hand-written SLI definition with a rate for an avg is returned as is
+	if gotValue := normalizeDataToRate("builtin:service.response.time:merge(0):avg", "MicroSecond,rate=minute", 600, startTime, startTime.Add(10*time.Minute)); gotValue != 600 {
+		t.Errorf("normalizeDataToRate() = %v, want 600 for an avg", gotValue)
+	}
+}
+
+func TestGetMetricUnitTimeframe(t *testing.T) {
+	startTime := time.Unix(1571649084, 0).UTC()
+	endTime := startTime.Add(time.Hour)
+
+	tests := []struct {
+		name      string
+		unit      string
+		wantStart time.Time
+		wantEnd   time.Time
+		wantErr   bool
+	}{
+		{name: "no options", unit: "Count", wantStart: startTime, wantEnd: endTime},
+		{name: "timeframe", unit: "Count,timeframe=-2h", wantStart: endTime.Add(-2 * time.Hour), wantEnd: endTime},
+		{name: "shift", unit: "Count,rate=minute,shift=-7d", wantStart: startTime.Add(-7 * 24 * time.Hour), wantEnd: endTime.Add(-7 * 24 * time.Hour)},
+		{name: "reference doesnt move the timeframe", unit: "Count,reference=-1w", wantStart: startTime, wantEnd: endTime},
+		{name: "invalid shift", unit: "Count,shift=7d", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			gotStart, gotEnd, err := getMetricUnitTimeframe(tt.unit, startTime, endTime)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("getMetricUnitTimeframe() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if !tt.wantErr && (!gotStart.Equal(tt.wantStart) || !gotEnd.Equal(tt.wantEnd)) {
+				t.Errorf("getMetricUnitTimeframe() = %v - %v, want %v - %v", gotStart, gotEnd, tt.wantStart, tt.wantEnd)
+			}
+		})
+	}
+}
+
+func TestGetTileWindow(t *testing.T) {
+	startTime := time.Unix(1571649084, 0).UTC()
+	endTime := startTime.Add(time.Hour)
+
+	tests := []struct {
+		name                string
+		tileTitle           string
+		tileTimeframe       string
+		wantWindowOption    string
+		wantReferenceOption string
+		wantStart           time.Time
+		wantErr             bool
+	}{
+		{name: "no window", tileTitle: "Response time;sli=rt", tileTimeframe: "-2h", wantStart: startTime},
+		{name: "tile timeframe", tileTitle: "Response time;sli=rt;window=tile", tileTimeframe: "now-30m to now", wantWindowOption: ",timeframe=-30m", wantStart: endTime.Add(-30 * time.Minute)},
+		{name: "shifted window with reference", tileTitle: "Response time;sli=rt;window=-1d;reference=-7d", wantWindowOption: ",shift=-1d", wantReferenceOption: ",reference=-7d", wantStart: startTime.Add(-24 * time.Hour)},
+		{name: "tile without timeframe", tileTitle: "Response time;sli=rt;window=tile", wantErr: true},
+		{name: "absolute tile timeframe", tileTitle: "Response time;sli=rt;window=tile", tileTimeframe: "today", wantErr: true},
+		{name: "invalid reference", tileTitle: "Response time;sli=rt;reference=last week", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			gotWindowOption, gotReferenceOption, gotStart, _, err := getTileWindow(tt.tileTitle, tt.tileTimeframe, startTime, endTime)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("getTileWindow() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if tt.wantErr {
+				return
+			}
+			if gotWindowOption != tt.wantWindowOption || gotReferenceOption != tt.wantReferenceOption {
+				t.Errorf("getTileWindow() options = %s %s, want %s %s", gotWindowOption, gotReferenceOption, tt.wantWindowOption, tt.wantReferenceOption)
+			}
+			if !gotStart.Equal(tt.wantStart) {
+				t.Errorf("getTileWindow() start = %v, want %v", gotStart, tt.wantStart)
+			}
+		})
+	}
+}
+
+// A tile whose window cant be evaluated fails with the indicator name & SLO it would have had - including the section prefix & defaults
+func TestProcessDashboardTilesWithInvalidWindow(t *testing.T) {
+	keptnEvent := testingGetKeptnEvent(QUALITYGATE_PROJECT, QUALITYGATE_STAGE, QUALTIYGATE_SERVICE, "", "")
+	dh, _, _, teardown := testingGetDynatraceHandler(keptnEvent)
+	defer teardown()
+
+	dashboardJSON := &DynatraceDashboard{}
+	err := json.Unmarshal([]byte(`{
+		"dashboardMetadata": {"name": "KQG"},
+		"tiles": [
+			{"name": "KQG.Section;prefix=perf_;pass=<1000", "tileType": "HEADER", "bounds": {"top": 0, "left": 0, "width": 1254, "height": 38}},
+			{"name": "Response time;sli=rt;window=tile", "tileType": "DATA_EXPLORER", "bounds": {"top": 38, "left": 0, "width": 304, "height": 304}},
+			{"name": "Custom chart", "tileType": "CUSTOM_CHARTING", "bounds": {"top": 38, "left": 304, "width": 304, "height": 304}, "filterConfig": {"customName": "Failures;sli=failures;window=tile"}}
+		]
+	}`), dashboardJSON)
+	if err != nil {
+		t.Fatalf("Couldnt parse dashboard: %v", err)
+	}
+
+	_, dashboardSLO, sliResults := dh.processDashboardTiles(dashboardJSON, time.Unix(1571649084, 0).UTC(), time.Unix(1571649085, 0).UTC())
+
+	wantIndicators := []string{"perf_rt", "perf_failures"}
+	if len(sliResults) != len(wantIndicators) || len(dashboardSLO.Objectives) != len(wantIndicators) {
+		t.Fatalf("Expected %d SLI results and SLOs but got %d and %d", len(wantIndicators), len(sliResults), len(dashboardSLO.Objectives))
+	}
+	for i, wantIndicator := range wantIndicators {
+		if sliResults[i].Metric != wantIndicator || sliResults[i].Success {
+			t.Errorf("Expected failed SLI result %s but got %+v", wantIndicator, sliResults[i])
+		}
+		objective := dashboardSLO.Objectives[i]
+		if objective.SLI != wantIndicator || len(objective.Pass) != 1 || objective.Pass[0].Criteria[0] != "<1000" {
+			t.Errorf("Expected SLO %s with the section pass criteria but got %+v", wantIndicator, objective)
+		}
+	}
+}
+
+func TestGetSLIValueWithWindowOptions(t *testing.T) {
+	keptnEvent := testingGetKeptnEvent(QUALITYGATE_PROJECT, QUALITYGATE_STAGE, QUALTIYGATE_SERVICE, "", "")
+	dh, _, _, teardown := testingGetDynatraceHandler(keptnEvent)
+	defer teardown()
+
+	dh.CustomQueries = make(map[string]string)
+	dh.CustomQueries["availability_last_week"] = "MV2;Percent,shift=-7d;metricSelector=builtin:synthetic.http.availability.location.total:splitBy():avg"
+	dh.CustomQueries["availability_ratio"] = "MV2;Percent,reference=-7d;metricSelector=builtin:synthetic.http.availability.location.total:splitBy():avg"
+	dh.CustomQueries["availability_invalid"] = "MV2;Percent,reference=7d;metricSelector=builtin:synthetic.http.availability.location.total:splitBy():avg"
+
+	startTime := time.Unix(1571649084, 0).UTC()
+	endTime := time.Unix(1571649085, 0).UTC()
+
+	// the test server returns the same value for every timeframe
+	if value, err := dh.GetSLIValue("availability_last_week", startTime, endTime); err != nil || value != 100 {
+		t.Errorf("GetSLIValue() for shifted timeframe = %v, %v - want 100", value, err)
+	}
+	if value, err := dh.GetSLIValue("availability_ratio", startTime, endTime); err != nil || value != 1 {
+		t.Errorf("GetSLIValue() for ratio = %v, %v - want 1", value, err)
+	}
+	if _, err := dh.GetSLIValue("availability_invalid", startTime, endTime); err == nil {
+		t.Errorf("GetSLIValue() should fail for an invalid reference")
+	}
+}
+
+// With writeMode merge user-authored indicators coexist with those of the dashboard - they have to be queried as the dashboard only returns results for its own
+func TestAddMissingSLIResults(t *testing.T) {
+	keptnEvent := testingGetKeptnEvent(QUALITYGATE_PROJECT, QUALITYGATE_STAGE, QUALTIYGATE_SERVICE, "", "")
+	dh, _, _, teardown := testingGetDynatraceHandler(keptnEvent)
+	defer teardown()
+
+	testingSetResourceStore(t, common.NewLocalResourceStore(t.TempDir()))
+
+	userSLI := `---
+spec_version: "1.0"
+indicators:
+  availability_user: MV2;Percent;metricSelector=builtin:synthetic.http.availability.location.total:splitBy():avg
+`
+	generatedSLI := []byte(`---
+spec_version: "0.1.4"
+indicators:
+  rt_dashboard: MV2;MicroSecond;metricSelector=builtin:service.response.time:merge(0):avg:names&entitySelector=type(SERVICE)
+`)
+	mergedSLI, err := common.MergeGeneratedSLI(userSLI, generatedSLI)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := common.UploadKeptnResource(mergedSLI, common.DynatraceSLIFilename, keptnEvent); err != nil {
+		t.Fatal(err)
+	}
+
+	dh.CustomQueries, err = common.GetCustomQueries(keptnEvent)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// the lighthouse asks for the indicators of all objectives of the merged slo.yaml
+	userSLO := `---
+objectives:
+- sli: availability_user
+  pass:
+  - criteria:
+    - ">=99"
+- sli: unknown_user
+`
+	generatedSLO := []byte(`---
+objectives:
+- sli: rt_dashboard
+  pass:
+  - criteria:
+    - "<=+10%"
+`)
+	mergedSLO, err := common.MergeGeneratedSLO(userSLO, generatedSLO)
+	if err != nil {
+		t.Fatal(err)
+	}
+	slo := &keptn.ServiceLevelObjectives{}
+	if err := yaml.Unmarshal(mergedSLO, slo); err != nil {
+		t.Fatal(err)
+	}
+	var indicators []string
+	for _, objective := range slo.Objectives {
+		indicators = append(indicators, objective.SLI)
+	}
+
+	startTime := time.Unix(1571649084, 0).UTC()
+	endTime := time.Unix(1571649085, 0).UTC()
+	dashboardResults := []*keptnv2.SLIResult{{Metric: "rt_dashboard", Value: 10, Success: true}}
+	sliResults := dh.AddMissingSLIResults(indicators, dashboardResults, startTime, endTime)
+
+	// the result of the dashboard is kept, the user-authored indicator is queried & an unknown one fails
+	expectedResults := []*keptnv2.SLIResult{
+		{Metric: "rt_dashboard", Value: 10, Success: true},
+		{Metric: "availability_user", Value: 100, Success: true},
+	}
+	if len(sliResults) != 3 {
+		t.Fatalf("Expected 3 SLI results but got %d", len(sliResults))
+	}
+	if !reflect.DeepEqual(sliResults[:2], expectedResults) {
+		t.Errorf("AddMissingSLIResults() = %+v, %+v - want %+v, %+v", sliResults[0], sliResults[1], expectedResults[0], expectedResults[1])
+	}
+	if sliResults[2].Metric != "unknown_user" || sliResults[2].Success {
+		t.Errorf("Expected a failed SLI result for unknown_user but got %+v", sliResults[2])
+	}
+}
+
+func TestGenerateRatioSLIs(t *testing.T) {
+	keptnEvent := testingGetKeptnEvent(QUALITYGATE_PROJECT, QUALITYGATE_STAGE, QUALTIYGATE_SERVICE, "", "")
+	dh, _, _, teardown := testingGetDynatraceHandler(keptnEvent)
+	defer teardown()
+
+	dashboardSLI := &SLI{Indicators: map[string]string{
+		"availability": "MV2;Percent,shift=-1d;metricSelector=builtin:synthetic.http.availability.location.total:splitBy():avg",
+		"problems":     "PV2;problemSelector=status(open)",
+	}}
+	dashboardSLO := &keptn.ServiceLevelObjectives{}
+	sliResults := []*keptnv2.SLIResult{
+		{Metric: "availability", Value: 100, Success: true},
+		{Metric: "problems", Value: 0, Success: true},
+	}
+	passSLOs, warningSLOs := getRatioCriteria("Availability;sli=availability;pass=>=99;ratio.pass=>=0.99;reference=-7d")
+
+	ratioResults := dh.generateRatioSLIs(sliResults, ",reference=-7d", passSLOs, warningSLOs, 1, dashboardSLI, dashboardSLO, time.Unix(1571649084, 0).UTC(), time.Unix(1571649085, 0).UTC())
+
+	// only metric queries can be compared with a reference
+	if len(ratioResults) != 1 || ratioResults[0].Metric != "availability_ratio" || !ratioResults[0].Success || ratioResults[0].Value != 1 {
+		t.Fatalf("generateRatioSLIs() = %v, want one availability_ratio of 1", ratioResults)
+	}
+	if query := dashboardSLI.Indicators["availability_ratio"]; query != "MV2;Percent,shift=-1d,reference=-7d;metricSelector=builtin:synthetic.http.availability.location.total:splitBy():avg" {
+		t.Errorf("generateRatioSLIs() generated query %s", query)
+	}
+	if len(dashboardSLO.Objectives) != 1 || len(dashboardSLO.Objectives[0].Pass) != 1 || dashboardSLO.Objectives[0].Pass[0].Criteria[0] != ">=0.99" || dashboardSLO.Objectives[0].Warning != nil {
+		t.Errorf("generateRatioSLIs() should use the ratio criteria only")
+	}
+}
+
+func TestGetChartMetricAggregation(t *testing.T) {
+	tests := []struct {
+		name            string
+		aggregation     string
+		percentile      interface{}
+		supportedTypes  []string
+		wantAggregation string
+		wantUnit        string
+		wantErr         bool
+	}{
+		{name: "avg", aggregation: "AVG", wantAggregation: "avg", wantUnit: "Percent"},
+		{name: "percentile", aggregation: "PERCENTILE", percentile: 90.0, supportedTypes: []string{"auto", "percentile"}, wantAggregation: "percentile(90.000000)", wantUnit: "Percent"},
+		{name: "percentile without percentile", aggregation: "PERCENTILE", wantErr: true},
+		{name: "of interest ratio", aggregation: "OF_INTEREST_RATIO", wantAggregation: "avg", wantUnit: "Percent"},
+		{name: "other ratio", aggregation: "OTHER_RATIO", wantAggregation: "avg", wantUnit: "Percent,ratio=other"},
+		{name: "unsupported by metric", aggregation: "SUM", supportedTypes: []string{"auto", "avg"}, wantErr: true},
+		{name: "unknown aggregation", aggregation: "SUM_DIMENSION_RATIO", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			gotAggregation, gotUnit, err := getChartMetricAggregation(tt.aggregation, tt.percentile, "Percent", tt.supportedTypes)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("getChartMetricAggregation() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if gotAggregation != tt.wantAggregation || gotUnit != tt.wantUnit {
+				t.Errorf("getChartMetricAggregation() = %v, %v, want %v, %v", gotAggregation, gotUnit, tt.wantAggregation, tt.wantUnit)
+			}
+		})
+	}
 }
 
 func TestParsePassAndWarningFromString(t *testing.T) {
@@ -795,6 +2372,104 @@ func TestParsePassAndWarningFromString(t *testing.T) {
 	}
 }
 
+func TestApplySectionDefaults(t *testing.T) {
+	tests := []struct {
+		name     string
+		tileName string
+		header   string
+		want     string
+	}{
+		{
+			name:     "prefix and defaults",
+			tileName: "Response time;sli=rt",
+			header:   "KQG.Section;prefix=perf_;weight=2;key=true;pass=<1000",
+			want:     "Response time;sli=perf_rt;weight=2;key=true;pass=<1000",
+		},
+		{
+			name:     "tile values win",
+			tileName: "Response time;sli=rt;pass=<500;weight=1",
+			header:   "KQG.Section;prefix=perf_;weight=2;pass=<1000;warning=<2000",
+			want:     "Response time;sli=perf_rt;pass=<500;weight=1;warning=<2000",
+		},
+		{
+			name:     "tile without sli isnt included",
+			tileName: "Response time",
+			header:   "KQG.Section;prefix=perf_;weight=2",
+			want:     "Response time",
+		},
+		{
+			name:     "no section",
+			tileName: "Response time;sli=rt",
+			header:   "Performance",
+			want:     "Response time;sli=rt",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			section, _ := common.ParseSectionHeader(tt.header)
+			if got := common.ApplySectionDefaults(tt.tileName, section); got != tt.want {
+				t.Errorf("ApplySectionDefaults() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestGetDashboardSectionDefaults(t *testing.T) {
+	sections := []dashboardSection{
+		{top: 0, left: 0, width: 608, defaults: "performance"},
+		{top: 0, left: 646, width: 608, defaults: "errors"},
+		{top: 380, left: 0, width: 1254, defaults: "infrastructure"},
+	}
+
+	tests := []struct {
+		name              string
+		top, left, width  int
+		wantSectionConfig string
+	}{
+		{name: "left column", top: 38, left: 0, width: 304, wantSectionConfig: "performance"},
+		{name: "right column", top: 38, left: 950, width: 304, wantSectionConfig: "errors"},
+		{name: "below full width header", top: 418, left: 950, width: 304, wantSectionConfig: "infrastructure"},
+		{name: "above all headers", top: -38, left: 0, width: 304, wantSectionConfig: ""},
+		{name: "no overlapping header", top: 38, left: 1300, width: 304, wantSectionConfig: ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := getDashboardSectionDefaults(sections, tt.top, tt.left, tt.width); got != tt.wantSectionConfig {
+				t.Errorf("getDashboardSectionDefaults() = %v, want %v", got, tt.wantSectionConfig)
+			}
+		})
+	}
+}
+
+func TestGetDashboardConfiguration(t *testing.T) {
+	dashboardJSON := &DynatraceDashboard{}
+	err := json.Unmarshal([]byte(`{
+		"dashboardMetadata": {"name": "KQG;project=sockshop", "tags": ["keptn_project:sockshop", "kqg.total.pass:80%", "kqg.total.warning:60%", "kqg.exclude:Debug chart", "kqg.compare.results:x"]},
+		"tiles": [
+			{"name": "Markdown", "tileType": "MARKDOWN", "markdown": "KQG.Total.Pass=95%\nKQG.Exclude=tmp_rt"}
+		]
+	}`), dashboardJSON)
+	if err != nil {
+		t.Fatalf("Couldnt parse dashboard: %v", err)
+	}
+
+	markdownConfig, problems := getDashboardConfiguration(dashboardJSON)
+
+	// the markdown wins over the tags
+	totalScore := getDashboardTotalScore(dashboardJSON)
+	if totalScore.Pass != "95%" || totalScore.Warning != "60%" {
+		t.Errorf("Expected total score 95%%/60%% but got %s/%s", totalScore.Pass, totalScore.Warning)
+	}
+	if !reflect.DeepEqual(markdownConfig.Exclude, []string{"Debug chart", "tmp_rt"}) {
+		t.Errorf("Expected exclusions of tags and markdown but got %v", markdownConfig.Exclude)
+	}
+
+	wantProblems := []string{"dashboard 'KQG;project=sockshop' tag 'kqg.compare.results:x': kqg.compare.results=x is not a positive number - using 1"}
+	if !reflect.DeepEqual(problems, wantProblems) {
+		t.Errorf("getDashboardConfiguration() problems = %v, want %v", problems, wantProblems)
+	}
+}
+
 func TestParseMarkdownConfiguration(t *testing.T) {
 
 	dashboardSLO1 := &keptn.ServiceLevelObjectives{
@@ -858,7 +2533,11 @@ func TestParseMarkdownConfiguration(t *testing.T) {
 		TotalScore: &keptn.SLOScore{Pass: "", Warning: ""},
 		Comparison: &keptn.SLOComparison{CompareWith: "", IncludeResultWithScore: "", NumberOfComparisonResults: 0, AggregateFunction: ""},
 	}
-	common.ParseMarkdownConfiguration("KQG.Total.Pass=50%;KQG.Total.Warning=40%;KQG.Compare.WithScore=pass;KQG.Compare.Results=3;KQG.Compare.Function=INVALID", dashboardSLO3)
+	problems := common.ParseMarkdownConfiguration("KQG.Total.Pass=50%;KQG.Total.Warning=40%;KQG.Compare.WithScore=pass;KQG.Compare.Results=3;KQG.Compare.Function=INVALID", dashboardSLO3)
+
+	if len(problems) != 1 || problems[0].Column != 112 {
+		t.Errorf("Expected the invalid KQG.Compare.Function at column 112 to be reported - got %v", problems)
+	}
 
 	if dashboardSLO3.TotalScore.Pass != "50%" {
 		t.Errorf("Total Pass not 50% - is " + dashboardSLO3.TotalScore.Pass)
@@ -0,0 +1,348 @@
+package dynatrace
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+/**
+ * USQL tile types the values of a USQL result can be decoded for
+ */
+const USQLTileTypeSingleValue = "SINGLE_VALUE"
+const USQLTileTypePieChart = "PIE_CHART"
+const USQLTileTypeColumnChart = "COLUMN_CHART"
+const USQLTileTypeTable = "TABLE"
+const USQLTileTypeLineChart = "LINE_CHART"
+
+// USQLAllValueColumns selects all columns except the dimension column - and the time column of a line chart - as value columns, e.g: value=*
+const USQLAllValueColumns = "*"
+
+// usqlStatementExpression detects bare USQL statements, e.g: select count(internalUserId) FROM usersession where ...
+var usqlStatementExpression = regexp.MustCompile(`(?is)^\s*select\s.+\sfrom\s+(usersession|useraction|userevent|usererror)\b`)
+
+// usqlSLIDefinition is a parsed USQL SLI definition
+type usqlSLIDefinition struct {
+	TileType        string
+	DimensionColumn string
+	ValueColumns    []string
+	Dimension       string
+	Query           string
+}
+
+// USQLValue is a single value decoded from a USQL result
+type USQLValue struct {
+	// Dimension is the content of the dimension column - empty for tiles without a dimension, e.g: SINGLE_VALUE
+	Dimension      string
+	DimensionIndex int
+
+	// Column & ColumnIndex identify the value column
+	Column      string
+	ColumnIndex int
+
+	Value float64
+
+	// Err is set if the cell couldnt be decoded, e.g: a null value or a string that is not a number
+	Err error
+}
+
+/**
+ * Decodes the values of a USQL result the way the tile type shows them:
+ * SINGLE_VALUE: the first column of the first row
+ * PIE_CHART, COLUMN_CHART: the first column is the dimension and the second column is the value
+ * TABLE: the first column is the dimension and the last column is the value
+ * LINE_CHART: the first column is the time, the second column is the dimension if there are more than two columns and the last column is the value - values are averaged per dimension
+ *
+ * dimensionColumn & valueColumns overwrite the default columns by name or index, e.g: city or 0. valueColumns can be * to take all columns but the dimension as value columns
+ * Returns an error for unsupported tile types or unknown columns. Cells that cant be decoded are returned as USQLValue with Err
+ */
+func DecodeUSQLResult(result *DTUSQLResult, tileType string, dimensionColumn string, valueColumns []string) ([]*USQLValue, error) {
+	columnCount := len(result.ColumnNames)
+	if columnCount == 0 && len(result.Values) > 0 {
+		columnCount = len(result.Values[0])
+	}
+
+	// the default columns of the tile type
+	dimensionIndex := 0
+	firstValueIndex := 0
+	var valueIndexes []int
+	switch tileType {
+	case USQLTileTypeSingleValue:
+		dimensionIndex = -1
+		valueIndexes = []int{0}
+	case USQLTileTypePieChart, USQLTileTypeColumnChart:
+		valueIndexes = []int{1}
+	case USQLTileTypeTable:
+		valueIndexes = []int{columnCount - 1}
+	case USQLTileTypeLineChart:
+		dimensionIndex = -1
+		firstValueIndex = 1
+		if columnCount > 2 {
+			dimensionIndex = 1
+		}
+		valueIndexes = []int{columnCount - 1}
+	default:
+		return nil, fmt.Errorf("Unsupported USQL tile type %s", tileType)
+	}
+
+	var err error
+	if dimensionColumn != "" {
+		dimensionIndex, err = getUSQLColumnIndex(result.ColumnNames, columnCount, dimensionColumn)
+		if err != nil {
+			return nil, err
+		}
+	}
+	if len(valueColumns) == 1 && valueColumns[0] == USQLAllValueColumns {
+		valueIndexes = nil
+		for ix := firstValueIndex; ix < columnCount; ix++ {
+			if ix != dimensionIndex {
+				valueIndexes = append(valueIndexes, ix)
+			}
+		}
+	} else if len(valueColumns) > 0 {
+		valueIndexes = nil
+		for _, valueColumn := range valueColumns {
+			valueIndex, err := getUSQLColumnIndex(result.ColumnNames, columnCount, valueColumn)
+			if err != nil {
+				return nil, err
+			}
+			valueIndexes = append(valueIndexes, valueIndex)
+		}
+	}
+	for _, valueIndex := range valueIndexes {
+		if valueIndex < 0 || valueIndex >= columnCount {
+			return nil, fmt.Errorf("USQL result with %d columns has no value column for tile type %s", columnCount, tileType)
+		}
+	}
+
+	rows := result.Values
+	if tileType == USQLTileTypeSingleValue && len(rows) > 1 {
+		rows = rows[:1]
+	}
+
+	var values []*USQLValue
+	for rowIx, row := range rows {
+		dimension := ""
+		if dimensionIndex >= 0 && dimensionIndex < len(row) {
+			dimension = usqlCellToString(row[dimensionIndex])
+		}
+
+		for _, valueIndex := range valueIndexes {
+			value := &USQLValue{Dimension: dimension, DimensionIndex: dimensionIndex, Column: getUSQLColumnName(result.ColumnNames, valueIndex), ColumnIndex: valueIndex}
+			if valueIndex >= len(row) {
+				value.Err = fmt.Errorf("row %d has no column %s", rowIx+1, value.Column)
+			} else if value.Value, err = usqlCellToFloat(row[valueIndex]); err != nil {
+				value.Err = fmt.Errorf("row %d, column %s: %v", rowIx+1, value.Column, err)
+			}
+			values = append(values, value)
+		}
+	}
+
+	if tileType == USQLTileTypeLineChart {
+		values = averageUSQLValues(values)
+	}
+
+	return values, nil
+}
+
+/**
+ * Averages the values of a line chart per dimension & value column. Cells without a value, e.g: null for a time without data, are left out
+ */
+func averageUSQLValues(values []*USQLValue) []*USQLValue {
+	var averages []*USQLValue
+	counts := map[string]int{}
+	averageByKey := map[string]*USQLValue{}
+	for _, value := range values {
+		key := fmt.Sprintf("%d;%s", value.ColumnIndex, value.Dimension)
+		average, found := averageByKey[key]
+		if !found {
+			average = &USQLValue{Dimension: value.Dimension, DimensionIndex: value.DimensionIndex, Column: value.Column, ColumnIndex: value.ColumnIndex, Err: value.Err}
+			averageByKey[key] = average
+			averages = append(averages, average)
+		}
+		if value.Err != nil {
+			continue
+		}
+		average.Value = (average.Value*float64(counts[key]) + value.Value) / float64(counts[key]+1)
+		average.Err = nil
+		counts[key]++
+	}
+	return averages
+}
+
+/**
+ * Returns the index of a column selected by name (case insensitive) or index
+ */
+func getUSQLColumnIndex(columnNames []string, columnCount int, column string) (int, error) {
+	if index, err := strconv.Atoi(column); err == nil {
+		if index < 0 || index >= columnCount {
+			return -1, fmt.Errorf("USQL result with %d columns has no column %d", columnCount, index)
+		}
+		return index, nil
+	}
+
+	for ix, columnName := range columnNames {
+		if strings.EqualFold(columnName, column) {
+			return ix, nil
+		}
+	}
+	return -1, fmt.Errorf("USQL result has no column %s - columns are: %s", column, strings.Join(columnNames, ", "))
+}
+
+func getUSQLColumnName(columnNames []string, index int) string {
+	if index < len(columnNames) {
+		return columnNames[index]
+	}
+	return strconv.Itoa(index)
+}
+
+/**
+ * Decodes a value cell. Numbers, numeric strings & booleans are supported - null, lists and other strings are not
+ */
+func usqlCellToFloat(cell interface{}) (float64, error) {
+	switch value := cell.(type) {
+	case nil:
+		return 0, errors.New("value is null")
+	case float64:
+		return value, nil
+	case json.Number:
+		return value.Float64()
+	case bool:
+		if value {
+			return 1, nil
+		}
+		return 0, nil
+	case string:
+		floatValue, err := strconv.ParseFloat(strings.TrimSpace(value), 64)
+		if err != nil {
+			return 0, fmt.Errorf("'%s' is not a number", value)
+		}
+		return floatValue, nil
+	default:
+		return 0, fmt.Errorf("%v is not a number", value)
+	}
+}
+
+/**
+ * Decodes a dimension cell. Every column type is supported: numbers are formatted without exponent, lists are joined with a comma and null becomes "null"
+ */
+func usqlCellToString(cell interface{}) string {
+	switch value := cell.(type) {
+	case nil:
+		return "null"
+	case string:
+		return value
+	case float64:
+		return strconv.FormatFloat(value, 'f', -1, 64)
+	case []interface{}:
+		var values []string
+		for _, listValue := range value {
+			values = append(values, usqlCellToString(listValue))
+		}
+		return strings.Join(values, ",")
+	default:
+		return fmt.Sprint(value)
+	}
+}
+
+/**
+ * Returns the columns selected by the tile title, e.g: sli=city_duration;dimension=city;value=avg(duration),count(*)
+ */
+func getUSQLColumnsFromTileTitle(tileTitle string) (string, []string) {
+	dimensionColumn := ""
+	var valueColumns []string
+	for _, nameValue := range strings.Split(tileTitle, ";") {
+		nameValueSplits := strings.SplitN(nameValue, "=", 2)
+		if len(nameValueSplits) != 2 {
+			continue
+		}
+		switch strings.ToLower(strings.TrimSpace(nameValueSplits[0])) {
+		case "dimension":
+			dimensionColumn = strings.TrimSpace(nameValueSplits[1])
+		case "value":
+			valueColumns = nil
+			for _, valueColumn := range strings.Split(nameValueSplits[1], ",") {
+				valueColumns = append(valueColumns, strings.TrimSpace(valueColumn))
+			}
+		}
+	}
+	return dimensionColumn, valueColumns
+}
+
+/**
+ * Parses the tile type of a USQL SLI definition which can select the columns, e.g: TABLE,dimension=0,value=2 of USQL;TABLE,dimension=0,value=2;Linz;SELECT ...
+ */
+func parseUSQLTileType(tileTypeWithOptions string) (string, string, []string) {
+	options := strings.Split(tileTypeWithOptions, ",")
+
+	dimensionColumn := ""
+	var valueColumns []string
+	for _, option := range options[1:] {
+		switch {
+		case strings.HasPrefix(option, "dimension="):
+			dimensionColumn = strings.TrimPrefix(option, "dimension=")
+		case strings.HasPrefix(option, "value="):
+			valueColumns = []string{strings.TrimPrefix(option, "value=")}
+		}
+	}
+	return options[0], dimensionColumn, valueColumns
+}
+
+/**
+ * Returns the tile type of a USQL SLI definition for a value - the columns are only added if they were selected explicitly, e.g: TABLE,value=2
+ */
+func getUSQLTileTypeForValue(tileType string, dimensionColumn string, valueColumns []string, value *USQLValue) string {
+	if dimensionColumn != "" && value.DimensionIndex >= 0 {
+		tileType = fmt.Sprintf("%s,dimension=%d", tileType, value.DimensionIndex)
+	}
+	if len(valueColumns) > 0 {
+		tileType = fmt.Sprintf("%s,value=%d", tileType, value.ColumnIndex)
+	}
+	return tileType
+}
+
+/**
+ * Parses a USQL SLI definition. The following formats are supported:
+ * USQL;TILE_TYPE;DIMENSION;QUERY, e.g: USQL;TABLE,value=2;Linz;SELECT city, count(*), avg(duration) FROM usersession GROUP BY city
+ * USQL;COLUMN;QUERY picks the column of the first row by name or index, e.g: USQL;Session Count;SELECT count(*) as "Session Count", avg(duration) FROM usersession
+ * USQL;QUERY and bare statements, e.g: SELECT count(*) FROM usersession, take the first column of the first row
+ * Returns nil if the definition isnt USQL
+ */
+func parseUSQLSLIDefinition(definition string) (*usqlSLIDefinition, error) {
+	if usqlStatementExpression.MatchString(definition) {
+		return &usqlSLIDefinition{TileType: USQLTileTypeSingleValue, Query: strings.TrimSpace(definition)}, nil
+	}
+	if !strings.HasPrefix(definition, "USQL;") {
+		return nil, nil
+	}
+
+	usql := strings.TrimPrefix(definition, "USQL;")
+	if usqlStatementExpression.MatchString(usql) {
+		return &usqlSLIDefinition{TileType: USQLTileTypeSingleValue, Query: strings.TrimSpace(usql)}, nil
+	}
+
+	// the query itself can contain ; - so we only split off the fields in front of it
+	querySplits := strings.SplitN(usql, ";", 3)
+	if len(querySplits) == 3 && (isUSQLTileType(querySplits[0]) || usqlStatementExpression.MatchString(querySplits[2])) {
+		tileType, dimensionColumn, valueColumns := parseUSQLTileType(querySplits[0])
+		return &usqlSLIDefinition{TileType: tileType, DimensionColumn: dimensionColumn, ValueColumns: valueColumns, Dimension: querySplits[1], Query: querySplits[2]}, nil
+	}
+
+	querySplits = strings.SplitN(usql, ";", 2)
+	if len(querySplits) == 2 && usqlStatementExpression.MatchString(querySplits[1]) {
+		return &usqlSLIDefinition{TileType: USQLTileTypeSingleValue, ValueColumns: []string{strings.TrimSpace(querySplits[0])}, Query: strings.TrimSpace(querySplits[1])}, nil
+	}
+
+	return nil, fmt.Errorf("USQL Query incorrect format: %s", definition)
+}
+
+func isUSQLTileType(tileTypeWithOptions string) bool {
+	switch strings.Split(tileTypeWithOptions, ",")[0] {
+	case USQLTileTypeSingleValue, USQLTileTypePieChart, USQLTileTypeColumnChart, USQLTileTypeTable, USQLTileTypeLineChart:
+		return true
+	}
+	return false
+}